@@ -0,0 +1,208 @@
+// Package audit records who issued which command in an append-only log
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/momper14/rotatefilehook"
+	"github.com/momper14/viperfix"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Entry an entry of the audit log
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Instance   string    `json:"instance,omitempty"`
+	User       string    `json:"user"`
+	RemoteAddr string    `json:"remoteAddr,omitempty"`
+	Target     string    `json:"target"`
+	Payload    string    `json:"payload"`
+	Success    bool      `json:"success"`
+	Result     string    `json:"result,omitempty"`
+}
+
+// Filter filters the entries of the audit log
+// empty fields match all entries
+type Filter struct {
+	Instance string
+	User     string
+	Since    time.Time
+	Until    time.Time
+}
+
+// match checks if the entry matches the filter
+func (f Filter) match(e Entry) bool {
+	if f.Instance != "" && f.Instance != e.Instance {
+		return false
+	}
+	if f.User != "" && f.User != e.User {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+var (
+	once     sync.Once
+	mu       sync.Mutex
+	hook     *rotatefilehook.RotateFileHook
+	filename string
+)
+
+// inits viper
+func init() {
+	viper.SetDefault("audit.filename", "logs/audit.log")
+}
+
+// open opens the audit log, rotated like the main log
+// done lazily, because the config isn't read while initialising the package
+func open() {
+	var c struct {
+		Compress bool
+		Max      struct {
+			Size    int
+			Backups int
+			Age     int
+		}
+	}
+
+	if err := viperfix.UnmarshalKey("log", &c); err != nil {
+		logrus.Error(err)
+	}
+	filename = viper.GetString("audit.filename")
+
+	var err error
+	hook, err = rotatefilehook.NewRotateFileHook(rotatefilehook.RotateFileConfig{
+		Filename:   filename,
+		MaxSize:    c.Max.Size,
+		MaxBackups: c.Max.Backups,
+		MaxAge:     c.Max.Age,
+		Compress:   c.Compress,
+	})
+	if err != nil {
+		logrus.Errorf("Failed to initialize audit log: %v", err)
+	}
+}
+
+// Record appends the entry to the audit log
+func Record(e Entry) {
+	once.Do(open)
+	if hook == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := hook.LogWriter.Write(append(line, '\n')); err != nil {
+		logrus.Errorf("Failed to write audit log: %v", err)
+	}
+}
+
+// RecordCommand records the command with its result
+func RecordCommand(command *model.Command, output string, err error) {
+	e := Entry{
+		Instance:   command.Instance,
+		User:       command.Issuer(),
+		RemoteAddr: command.RemoteAddr,
+		Target:     command.Target.String(),
+		Payload:    command.Payload,
+		Success:    err == nil,
+		Result:     output,
+	}
+	if err != nil {
+		e.Result = err.Error()
+	}
+
+	Record(e)
+}
+
+// Query returns the entries of the current and rotated audit logs matching the filter
+// sorted by time
+func Query(f Filter) ([]Entry, error) {
+	once.Do(open)
+
+	ext := filepath.Ext(filename)
+	files, err := filepath.Glob(strings.TrimSuffix(filename, ext) + "*" + ext + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0)
+	for _, file := range files {
+		if err := readEntries(file, f, &entries); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// readEntries reads the entries matching the filter of the file
+// rotated files may be gzip compressed
+func readEntries(file string, f Filter, entries *[]Entry) error {
+	fh, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var r io.Reader = fh
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(fh)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	// read by lines of any length, a long command mustn't hide the following entries
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var e Entry
+			if err := json.Unmarshal(line, &e); err != nil {
+				logrus.Warnf("invalid audit log entry in %s: %v", file, err)
+			} else if f.match(e) {
+				*entries = append(*entries, e)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Package cron parses cron expressions and calculates their next activation
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field bounds of a cron expression
+type bounds struct {
+	min, max int
+}
+
+var (
+	minutes  = bounds{0, 59}
+	hours    = bounds{0, 23}
+	days     = bounds{1, 31}
+	months   = bounds{1, 12}
+	weekdays = bounds{0, 7}
+)
+
+// macros maps the supported macros to their expression
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Schedule a parsed cron expression
+type Schedule struct {
+	expr    string
+	minute  uint64
+	hour    uint64
+	day     uint64
+	month   uint64
+	weekday uint64
+	anyDay  bool
+	anyWeek bool
+}
+
+// Parse parses a cron expression with the fields
+// minute, hour, day of month, month and day of week
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if val, ok := macros[spec]; ok {
+		spec = val
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{
+		expr:    expr,
+		anyDay:  fields[2] == "*",
+		anyWeek: fields[4] == "*",
+	}
+
+	var err error
+	if s.minute, err = parseField(fields[0], minutes); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hours); err != nil {
+		return nil, err
+	}
+	if s.day, err = parseField(fields[2], days); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], months); err != nil {
+		return nil, err
+	}
+	if s.weekday, err = parseField(fields[4], weekdays); err != nil {
+		return nil, err
+	}
+
+	// sunday can be written as 0 or 7
+	if s.weekday&(1<<7) != 0 {
+		s.weekday |= 1
+	}
+
+	return s, nil
+}
+
+// parseField parses a comma separated field to a bitset
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		from, to, step := b.min, b.max, 1
+
+		rng := part
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			rng = part[:i]
+		}
+
+		if rng != "*" {
+			var err error
+			if i := strings.Index(rng, "-"); i >= 0 {
+				if from, err = strconv.Atoi(rng[:i]); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", part)
+				}
+				if to, err = strconv.Atoi(rng[i+1:]); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", part)
+				}
+			} else {
+				if from, err = strconv.Atoi(rng); err != nil {
+					return 0, fmt.Errorf("invalid value in %q", part)
+				}
+				if step == 1 {
+					to = from
+				}
+			}
+		}
+
+		if from < b.min || to > b.max || from > to {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, b.min, b.max)
+		}
+
+		for i := from; i <= to; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+
+	return bits, nil
+}
+
+// String returns the original expression
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// matchDay checks if the day matches the day of month and day of week fields
+// if both are restricted, one of them has to match
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.day&(1<<uint(t.Day())) != 0
+	dow := s.weekday&(1<<uint(t.Weekday())) != 0
+
+	if s.anyDay || s.anyWeek {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the next activation after t
+// returns the zero time if there is none within the next five years
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,141 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseField(t *testing.T) {
+	tests := []struct {
+		field string
+		b     bounds
+		want  []int
+	}{
+		{"*", hours, seq(0, 23, 1)},
+		{"5", minutes, []int{5}},
+		{"1,15,30", days, []int{1, 15, 30}},
+		{"1-5", weekdays, seq(1, 5, 1)},
+		{"*/15", minutes, []int{0, 15, 30, 45}},
+		{"10-20/5", minutes, []int{10, 15, 20}},
+		{"50/3", minutes, []int{50, 53, 56, 59}},
+		{"0-6/2,7", weekdays, []int{0, 2, 4, 6, 7}},
+		{"12", months, []int{12}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseField(tt.field, tt.b)
+		if err != nil {
+			t.Errorf("parseField(%q) error = %v", tt.field, err)
+			continue
+		}
+		if want := bitset(tt.want); got != want {
+			t.Errorf("parseField(%q) = %b, want %b", tt.field, got, want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"-1 * * * *",
+		"1,,2 * * * *",
+		"@every",
+	}
+
+	for _, expr := range tests {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) error = nil, want an error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// Monday, 15 March 2021
+	from := time.Date(2021, 3, 15, 10, 30, 45, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2021, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2021, 3, 16, 10, 30, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2021, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2021, 3, 16, 4, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2021, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2021, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2021, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// sunday as 0 and 7
+		{"0 0 * * 0", time.Date(2021, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2021, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2021, 3, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 6", time.Date(2021, 3, 20, 12, 0, 0, 0, time.UTC)},
+		// only the day of month restricted
+		{"0 0 20 * *", time.Date(2021, 3, 20, 0, 0, 0, 0, time.UTC)},
+		// day of month and day of week restricted, either matches
+		{"0 0 20 * 3", time.Date(2021, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 16 * 5", time.Date(2021, 3, 16, 0, 0, 0, 0, time.UTC)},
+		// day of week restricted and day of month a star, both have to match
+		{"0 0 * 4 1", time.Date(2021, 4, 5, 0, 0, 0, 0, time.UTC)},
+		// the 31st is skipped in months without it
+		{"0 0 31 * *", time.Date(2021, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 4-6 *", time.Date(2021, 5, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// no activation within five years
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next() = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s, err := Parse(" @daily ")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if s.String() != " @daily " {
+		t.Errorf("String() = %q, want the original expression", s.String())
+	}
+}
+
+// seq returns the values from min to max with the step
+func seq(min, max, step int) []int {
+	var values []int
+	for i := min; i <= max; i += step {
+		values = append(values, i)
+	}
+	return values
+}
+
+// bitset returns the bits of the values
+func bitset(values []int) uint64 {
+	var bits uint64
+	for _, v := range values {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
@@ -3,17 +3,21 @@ module github.com/momper14/msw
 go 1.15
 
 require (
-	github.com/fsnotify/fsnotify v1.4.9 // indirect
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gorilla/mux v1.8.0
 	github.com/gorilla/websocket v1.4.2
 	github.com/looplab/fsm v0.2.0
 	github.com/mattn/go-colorable v0.1.8
 	github.com/momper14/rotatefilehook v1.0.1
 	github.com/momper14/viperfix v1.0.0
+	github.com/prometheus/client_golang v1.9.0
+	github.com/prometheus/procfs v0.2.0
 	github.com/shaj13/go-guardian/v2 v2.1.0
 	github.com/sirupsen/logrus v1.7.0
+	github.com/spf13/cast v1.3.0
 	github.com/spf13/viper v1.7.1
 	github.com/urfave/negroni v1.0.0
-	golang.org/x/sys v0.0.0-20200519105757-fe76b779f299 // indirect
-	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	go.etcd.io/bbolt v1.3.5
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
+	gopkg.in/yaml.v2 v2.4.0
 )
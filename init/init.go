@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/mattn/go-colorable"
+	"github.com/momper14/msw/reload"
 	"github.com/momper14/rotatefilehook"
 	"github.com/momper14/viperfix"
 	"github.com/sirupsen/logrus"
@@ -25,6 +26,8 @@ func init() {
 		logrus.Warn(err)
 	} else if err != nil {
 		logrus.Fatal(err)
+	} else {
+		reload.Snapshot()
 	}
 
 	viper.SetEnvPrefix("MSW")
@@ -64,19 +67,22 @@ func init() {
 	viper.SetDefault("log.max.age", 31)
 }
 
+// logConfig config of the logging
+type logConfig struct {
+	Filename        string
+	Compress        bool
+	Level           string
+	Timestampformat string
+	Max             struct {
+		Size    int
+		Backups int
+		Age     int
+	}
+}
+
 // init logrus
 func init() {
-	var c struct {
-		Filename        string
-		Compress        bool
-		Level           string
-		Timestampformat string
-		Max             struct {
-			Size    int
-			Backups int
-			Age     int
-		}
-	}
+	var c logConfig
 
 	if err := viperfix.UnmarshalKey("log", &c); err != nil {
 		logrus.Fatal(err)
@@ -91,7 +97,8 @@ func init() {
 		MaxBackups: c.Max.Backups,
 		MaxAge:     c.Max.Age,
 		Compress:   c.Compress,
-		Level:      logLevel,
+		// the level is filtered by the logger, so it can be changed on reload
+		Level: logrus.TraceLevel,
 		Formatter: &logrus.TextFormatter{
 			DisableColors:   true,
 			FullTimestamp:   true,
@@ -111,4 +118,24 @@ func init() {
 		TimestampFormat: timestampFormat,
 	})
 	logrus.AddHook(rotateFileHook)
+
+	reload.Register("log", func() (func(), error) { return reloadLog(c) })
+}
+
+// reloadLog applies the log level of the reread config
+// the other log settings are only applied on restart of the MSW
+func reloadLog(current logConfig) (func(), error) {
+	var c logConfig
+	if err := viperfix.UnmarshalKey("log", &c); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		logrus.SetLevel(strToLogrusLevel(c.Level))
+
+		c.Level = current.Level
+		if c != current {
+			logrus.Warn("changes of the log file settings require restarting the MSW")
+		}
+	}, nil
 }
@@ -0,0 +1,121 @@
+package logsearch
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Format parses the lines of a kind of log and recognises its rotated files
+type Format interface {
+	// parse parses the time and level of a line
+	// continuation lines like stack traces aren't parsed
+	parse(line string) (t time.Time, level string, ok bool)
+	// rotated reports if the file is a rotated log of the latest log
+	rotated(latest, name string) bool
+	// startDay returns the day a rotated log starts, zero if the lines have a full date
+	startDay(name string) time.Time
+}
+
+// serverFormat format of the logs of the Minecraft Server
+// [12:00:00] [Server thread/INFO]: message
+// the lines only have the time of the day, rotated logs are named by their day
+type serverFormat struct{}
+
+var (
+	serverLineRegexp    = regexp.MustCompile(`^\[(\d{2}:\d{2}:\d{2})\] \[[^\]]*/([A-Za-z]+)\]`)
+	serverRotatedRegexp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-\d+\.log(\.gz)?$`)
+)
+
+// ServerFormat returns the format of the logs of the Minecraft Server
+func ServerFormat() Format {
+	return serverFormat{}
+}
+
+// parse implements Format
+func (serverFormat) parse(line string) (time.Time, string, bool) {
+	match := serverLineRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, "", false
+	}
+
+	t, err := time.Parse("15:04:05", match[1])
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return t, normalizeLevel(match[2]), true
+}
+
+// rotated implements Format
+func (serverFormat) rotated(latest, name string) bool {
+	return serverRotatedRegexp.MatchString(name)
+}
+
+// startDay implements Format
+func (serverFormat) startDay(name string) time.Time {
+	match := serverRotatedRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", match[1], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return day
+}
+
+// mswFormat format of the logs of the MSW written by logrus
+// time="02-01-2006 15:04:05" level=info msg="message"
+// rotated logs are named latest-2006-01-02T15-04-05.000.log(.gz)
+type mswFormat struct {
+	timestampFormat string
+}
+
+var mswLineRegexp = regexp.MustCompile(`^time="([^"]*)" level=([a-z]+)`)
+
+// MSWFormat returns the format of the logs of the MSW with the timestamp format of log.timestampformat
+func MSWFormat(timestampFormat string) Format {
+	return mswFormat{timestampFormat: timestampFormat}
+}
+
+// parse implements Format
+func (f mswFormat) parse(line string) (time.Time, string, bool) {
+	match := mswLineRegexp.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, "", false
+	}
+
+	t, err := time.ParseInLocation(f.timestampFormat, match[1], time.Local)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return t, normalizeLevel(match[2]), true
+}
+
+// rotated implements Format
+func (mswFormat) rotated(latest, name string) bool {
+	ext := ".log"
+	if i := strings.LastIndex(latest, "."); i >= 0 {
+		latest, ext = latest[:i], latest[i:]
+	}
+
+	pattern := `^` + regexp.QuoteMeta(latest) + `-\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3}` + regexp.QuoteMeta(ext) + `(\.gz)?$`
+	ok, _ := regexp.MatchString(pattern, name)
+	return ok
+}
+
+// startDay implements Format
+// the lines have a full date
+func (mswFormat) startDay(name string) time.Time {
+	return time.Time{}
+}
+
+// normalizeLevel returns the level in lower case with warning shortened to warn
+func normalizeLevel(level string) string {
+	level = strings.ToLower(level)
+	if level == "warning" {
+		return "warn"
+	}
+	return level
+}
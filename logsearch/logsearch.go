@@ -0,0 +1,589 @@
+package logsearch
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLineSize maximum size of a line, longer lines are split
+const maxLineSize = 1 << 20
+
+// ErrInvalidCursor is returned for a cursor not created by Search
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Entry a line of a log
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Level string    `json:"level,omitempty"`
+	Line  string    `json:"line"`
+
+	// seq orders the entries of the same time
+	seq int
+}
+
+// Query filters and pages the entries
+// zero values don't filter
+type Query struct {
+	Since  time.Time
+	Until  time.Time
+	Levels []string
+	Regexp *regexp.Regexp
+	// Redact rewrites the lines before they are matched and returned
+	Redact func(line string) string
+	// Cursor continues with the entries before the last page
+	Cursor string
+	Limit  int
+}
+
+// Page the newest entries matching a query, oldest first
+// Next is the cursor of the previous entries, empty if there are none
+// a page can have less than Limit entries and a Next if the search ended early
+type Page struct {
+	Entries []Entry `json:"entries"`
+	Next    string  `json:"next,omitempty"`
+}
+
+// blockSize uncompressed bytes of a block of the index
+// a search reads the blocks which can contain matches instead of the whole file
+const blockSize = 64 << 10
+
+// maxFilesPerSearch maximum files read by a search, it returns a cursor to continue
+// a rarely matching filter would otherwise decompress every rotated log
+const maxFilesPerSearch = 4
+
+// headSize bytes at the start of the current log to recognise it was replaced
+const headSize = 64
+
+// parseState the state of parsing a log after a line
+type parseState struct {
+	// prev the previous entry, with the time of the day only for day-less formats
+	prev Entry
+	// day the days since the start of the file
+	day     int
+	parsed  bool
+	started bool
+}
+
+// stamp the unresolved time of an entry and its day
+type stamp struct {
+	time time.Time
+	day  int
+}
+
+// block a part of a log file starting at a line
+type block struct {
+	offset int64
+	// state the state before the first line
+	state parseState
+	first stamp
+	last  stamp
+}
+
+// fileIndex the blocks of a log file
+// rotated logs don't change, so their index stays valid,
+// the index of the current log is extended as it grows
+type fileIndex struct {
+	modTime time.Time
+	size    int64
+	// length uncompressed bytes indexed
+	length int64
+	head   string
+	// start the day of the first line, lines with the time of the day are on start plus their day
+	start  time.Time
+	blocks []block
+	end    parseState
+}
+
+// resolve returns the time of the entry on its day
+// lines without time before the first time of a day-less log are on the start day
+func (idx *fileIndex) resolve(st stamp) time.Time {
+	if st.time.Year() == 0 || (st.time.IsZero() && idx.end.prev.Time.Year() == 0) {
+		return withDay(idx.start.AddDate(0, 0, st.day), st.time)
+	}
+	return st.time
+}
+
+// firstTime returns the first time of the file, zero if no line has a time
+func (idx *fileIndex) firstTime() time.Time {
+	for _, b := range idx.blocks {
+		for _, st := range []stamp{b.first, b.last} {
+			if t := idx.resolve(st); !t.IsZero() {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// logFile a log file
+type logFile struct {
+	name    string
+	modTime time.Time
+	size    int64
+}
+
+// gzipped reports if the file is compressed
+func (f logFile) gzipped() bool {
+	return strings.HasSuffix(f.name, ".gz")
+}
+
+// Source the current and rotated logs of a directory
+type Source struct {
+	dir    string
+	latest string
+	format Format
+
+	mu      sync.Mutex
+	indexes map[string]*fileIndex
+}
+
+// NewSource initialises a Source of the logs in the directory
+// latest is the name of the current log
+func NewSource(dir, latest string, format Format) *Source {
+	return &Source{
+		dir:     dir,
+		latest:  latest,
+		format:  format,
+		indexes: make(map[string]*fileIndex),
+	}
+}
+
+// files returns the logs of the source, oldest first
+func (s *Source) files() ([]logFile, error) {
+	infos, err := ioutil.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files  []logFile
+		latest *logFile
+	)
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || (name != s.latest && !s.format.rotated(s.latest, name)) {
+			continue
+		}
+
+		f := logFile{name: name, modTime: info.ModTime(), size: info.Size()}
+		if name == s.latest {
+			latest = &f
+			continue
+		}
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	if latest != nil {
+		files = append(files, *latest)
+	}
+	return files, nil
+}
+
+// reader reads the uncompressed file from the offset
+type reader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes the file
+func (r *reader) Close() error {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		r.closers[i].Close()
+	}
+	return nil
+}
+
+// open opens the uncompressed file at the offset
+// compressed files can't seek, the bytes before the offset are decompressed and discarded
+func (s *Source) open(f logFile, offset int64) (*reader, error) {
+	file, err := os.Open(filepath.Join(s.dir, f.name))
+	if err != nil {
+		return nil, err
+	}
+	r := &reader{Reader: file, closers: []io.Closer{file}}
+
+	if !f.gzipped() {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			r.Close()
+			return nil, err
+		}
+		return r, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	r.Reader = gz
+	r.closers = append(r.closers, gz)
+	if _, err := io.CopyN(ioutil.Discard, gz, offset); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// scan parses the lines of r continuing the state
+// fn is called with the states before and after each line and its offset relative to r,
+// the entry of the line is the previous entry of the state after
+// lines without time inherit it from the previous line
+func (s *Source) scan(r io.Reader, st parseState, fn func(before, after parseState, offset int64)) (parseState, int64, error) {
+	var offset int64
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		offset += int64(advance)
+		return advance, token, err
+	})
+
+	for start := int64(0); scanner.Scan(); start = offset {
+		before := st
+		e := Entry{Line: scanner.Text(), Time: st.prev.Time, Level: st.prev.Level}
+		if t, level, ok := s.format.parse(e.Line); ok {
+			// the time of the day starts again on the next day
+			if st.parsed && t.Year() == 0 && t.Before(st.prev.Time) {
+				st.day++
+			}
+			e.Time, e.Level = t, level
+			st.parsed = true
+		}
+		// seq orders the entries of the same time
+		if st.started && e.Time.Equal(st.prev.Time) {
+			e.seq = st.prev.seq + 1
+		}
+		st.prev = e
+		st.started = true
+		fn(before, st, start)
+	}
+	return st, offset, scanner.Err()
+}
+
+// index returns the index of the file, building or extending it if the file changed
+// returns if the file was read
+func (s *Source) index(f logFile) (*fileIndex, bool, error) {
+	s.mu.Lock()
+	cached := s.indexes[f.name]
+	s.mu.Unlock()
+
+	if cached != nil && cached.modTime.Equal(f.modTime) && cached.size == f.size {
+		return cached, false, nil
+	}
+
+	head, err := s.head(f)
+	if err != nil {
+		return nil, false, err
+	}
+
+	idx := &fileIndex{modTime: f.modTime, size: f.size, head: head}
+	if cached != nil && !f.gzipped() && f.size > cached.size && cached.head == head && len(cached.blocks) > 0 {
+		// the current log grew, the last block is read again as its last line may have been incomplete
+		last := cached.blocks[len(cached.blocks)-1]
+		idx.blocks = append(make([]block, 0, len(cached.blocks)), cached.blocks[:len(cached.blocks)-1]...)
+		idx.length = last.offset
+		idx.end = last.state
+	}
+
+	r, err := s.open(f, idx.length)
+	if err != nil {
+		return nil, false, err
+	}
+	defer r.Close()
+
+	base := idx.length
+	end, length, err := s.scan(r, idx.end, func(before, after parseState, offset int64) {
+		st := stamp{time: after.prev.Time, day: after.day}
+		n := len(idx.blocks)
+		if n == 0 || base+offset-idx.blocks[n-1].offset >= blockSize {
+			idx.blocks = append(idx.blocks, block{offset: base + offset, state: before, first: st})
+			n++
+		}
+		idx.blocks[n-1].last = st
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	idx.end = end
+	idx.length = base + length
+
+	idx.start = s.format.startDay(f.name)
+	if idx.start.IsZero() {
+		// the current log ends on the day it was modified
+		y, m, d := f.modTime.Date()
+		idx.start = time.Date(y, m, d-end.day, 0, 0, 0, 0, time.Local)
+	}
+
+	s.mu.Lock()
+	s.indexes[f.name] = idx
+	s.mu.Unlock()
+	return idx, true, nil
+}
+
+// head returns the first bytes of the file
+func (s *Source) head(f logFile) (string, error) {
+	if f.gzipped() {
+		return "", nil
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, f.name))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, headSize)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	return string(buf[:n]), nil
+}
+
+// read reads the entries of the blocks lo to hi of the file, oldest first
+// lines with only the time of the day get the day of the file
+func (s *Source) read(f logFile, idx *fileIndex, lo, hi int) ([]Entry, error) {
+	start := idx.blocks[lo].offset
+	end := idx.length
+	if hi+1 < len(idx.blocks) {
+		end = idx.blocks[hi+1].offset
+	}
+
+	r, err := s.open(f, start)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []Entry
+	_, _, err = s.scan(io.LimitReader(r, end-start), idx.blocks[lo].state, func(_, after parseState, _ int64) {
+		e := after.prev
+		e.Time = idx.resolve(stamp{time: e.Time, day: after.day})
+		entries = append(entries, e)
+	})
+	return entries, err
+}
+
+// withDay returns the time of the day t on the day
+func withDay(day, t time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), day.Location())
+}
+
+// cursor returns the cursor of the entries before the entry
+func cursor(e Entry) string {
+	return fmt.Sprintf("%d.%d", e.Time.UnixNano(), e.seq)
+}
+
+// parseCursor parses a cursor created by cursor
+func parseCursor(c string) (time.Time, int, error) {
+	parts := strings.SplitN(c, ".", 2)
+	if len(parts) != 2 {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	seq, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return time.Time{}, 0, ErrInvalidCursor
+	}
+	return time.Unix(0, nanos), seq, nil
+}
+
+// matches reports if the entry matches the filters of the query
+func (q *Query) matches(e Entry) bool {
+	if !q.Since.IsZero() && e.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.Time.After(q.Until) {
+		return false
+	}
+	if len(q.Levels) > 0 {
+		found := false
+		for _, level := range q.Levels {
+			if normalizeLevel(level) == e.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return q.Regexp == nil || q.Regexp.MatchString(e.Line)
+}
+
+// Search returns the newest entries matching the query
+// the files and their blocks are searched newest first and skipped by their index if they can't match,
+// after maxFilesPerSearch files the page ends early with a cursor to continue
+func (s *Source) Search(q Query) (Page, error) {
+	var (
+		before    time.Time
+		beforeSeq int
+		err       error
+	)
+	if q.Cursor != "" {
+		if before, beforeSeq, err = parseCursor(q.Cursor); err != nil {
+			return Page{}, err
+		}
+	}
+
+	files, err := s.files()
+	if err != nil {
+		return Page{}, err
+	}
+
+	// collected newest first, one more than the limit to know if there are more
+	found := make([]Entry, 0)
+	full := func() bool { return q.Limit > 0 && len(found) > q.Limit }
+
+	// collect adds the matching entries before the cursor, newest first
+	collect := func(entries []Entry) {
+		for j := len(entries) - 1; j >= 0 && !full(); j-- {
+			e := entries[j]
+			if q.Redact != nil {
+				e.Line = q.Redact(e.Line)
+			}
+			if !before.IsZero() && (e.Time.After(before) || (e.Time.Equal(before) && e.seq >= beforeSeq)) {
+				continue
+			}
+			if q.matches(e) {
+				found = append(found, e)
+			}
+		}
+	}
+
+	var (
+		scanned int
+		resume  string
+		done    bool
+		i       int
+	)
+	for i = len(files) - 1; i >= 0 && !full() && !done; i-- {
+		if scanned == maxFilesPerSearch {
+			break
+		}
+
+		f := files[i]
+		idx, read, err := s.index(f)
+		if err != nil {
+			return Page{}, err
+		}
+		if read {
+			scanned++
+		}
+		if len(idx.blocks) == 0 {
+			continue
+		}
+
+		// the blocks which can contain matches
+		// a block starting at the cursor can't if it continues before the first entry of the time
+		lo, hi := 0, len(idx.blocks)-1
+		for ; hi >= 0; hi-- {
+			first := idx.resolve(idx.blocks[hi].first)
+			afterCursor := !before.IsZero() && (first.After(before) || (first.Equal(before) && beforeSeq == 0))
+			if (q.Until.IsZero() || !first.After(q.Until)) && !afterCursor {
+				break
+			}
+		}
+		if !q.Since.IsZero() {
+			for lo < len(idx.blocks) && idx.resolve(idx.blocks[lo].last).Before(q.Since) {
+				lo++
+			}
+			// the older files end before since too
+			done = lo > 0
+		}
+
+		if lo <= hi {
+			if !read {
+				scanned++
+			}
+			if f.gzipped() {
+				// compressed files are read in one pass, they can't seek
+				entries, err := s.read(f, idx, lo, hi)
+				if err != nil {
+					return Page{}, err
+				}
+				collect(entries)
+			}
+			for b := hi; b >= lo && !full() && !f.gzipped(); b-- {
+				entries, err := s.read(f, idx, b, b)
+				if err != nil {
+					return Page{}, err
+				}
+				collect(entries)
+			}
+		}
+
+		// continues before the first entry of the file
+		if first := idx.firstTime(); !first.IsZero() {
+			resume = cursor(Entry{Time: first})
+		}
+	}
+
+	page := Page{Entries: found}
+	switch {
+	case full():
+		page.Entries = found[:q.Limit]
+		page.Next = cursor(page.Entries[q.Limit-1])
+	case !done && i >= 0:
+		// files are left after reaching maxFilesPerSearch
+		page.Next = resume
+	}
+
+	for i, j := 0, len(page.Entries)-1; i < j; i, j = i+1, j-1 {
+		page.Entries[i], page.Entries[j] = page.Entries[j], page.Entries[i]
+	}
+	return page, nil
+}
+
+// Rotated calls fn with the entries of each rotated log, oldest first
+// the current log is skipped, it is still written
+func (s *Source) Rotated(fn func(entries []Entry) error) error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if f.name == s.latest {
+			continue
+		}
+
+		idx, _, err := s.index(f)
+		if err != nil {
+			return err
+		}
+		if len(idx.blocks) == 0 {
+			continue
+		}
+
+		entries, err := s.read(f, idx, 0, len(idx.blocks)-1)
+		if err != nil {
+			return err
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	_ "github.com/momper14/msw/init"
+	"github.com/momper14/msw/reload"
 	"github.com/momper14/msw/web"
 	"github.com/momper14/msw/wrapper"
 	"github.com/sirupsen/logrus"
@@ -23,10 +24,11 @@ func main() {
 	signal.Notify(quit, os.Interrupt)
 
 	mcController := wrapper.NewController()
-	webController := web.NewController(mcController.Wrapper())
+	webController := web.NewController(mcController.Wrappers())
 
 	go webController.Run()
 	go mcController.Run()
+	go reload.Watch()
 
 	<-quit
 
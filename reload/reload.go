@@ -0,0 +1,184 @@
+// Package reload rereads the config file on SIGHUP or when it changes and applies it
+package reload
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// debounce time to wait for further changes of the config file
+// editors often write a file in several steps
+const debounce = 500 * time.Millisecond
+
+// Hook prepares the reload of a part of the MSW from the reread config
+// all hooks are prepared before any is applied,
+// so an invalid config doesn't get applied partially
+type Hook func() (apply func(), err error)
+
+// namedHook a hook with the name it's reported with
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+var (
+	mu    sync.Mutex
+	hooks []namedHook
+	// active the content of the config file in use, restored if a reload is rejected
+	active []byte
+)
+
+// Register registers a hook called on reload
+// the hooks are applied in the order they are registered
+func Register(name string, hook Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	hooks = append(hooks, namedHook{name: name, hook: hook})
+}
+
+// Reload rereads the config file, validates and applies it
+// the hooks read the new config from viper, if one rejects it the previous config is restored
+func Reload() error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	content, err := ioutil.ReadFile(viper.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+	// parsed separately first, viper clears its config if reading fails
+	if err := parse(viper.New(), content); err != nil {
+		return err
+	}
+	if err := parse(viper.GetViper(), content); err != nil {
+		return err
+	}
+
+	applies := make([]func(), 0, len(hooks))
+	for _, h := range hooks {
+		apply, err := h.hook()
+		if err != nil {
+			if active != nil {
+				if err := parse(viper.GetViper(), active); err != nil {
+					logrus.Errorf("failed to restore the previous config: %v", err)
+				}
+			}
+			return fmt.Errorf("%s: %w", h.name, err)
+		}
+		applies = append(applies, apply)
+	}
+
+	active = content
+	for _, apply := range applies {
+		apply()
+	}
+	return nil
+}
+
+// parse reads the content in the format of the config file into v
+func parse(v *viper.Viper, content []byte) error {
+	v.SetConfigType(strings.TrimPrefix(filepath.Ext(viper.ConfigFileUsed()), "."))
+	return v.ReadConfig(bytes.NewReader(content))
+}
+
+// Snapshot keeps the content of the config file in use
+// it's restored if a reload is rejected, so it's taken right after the config is read at startup
+func Snapshot() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	content, err := ioutil.ReadFile(viper.ConfigFileUsed())
+	if err != nil {
+		logrus.Warnf("failed to read the config file: %v", err)
+		return
+	}
+	active = content
+}
+
+// Watch reloads the config on SIGHUP and when the config file changes
+func Watch() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var events chan fsnotify.Event
+	if file := viper.ConfigFileUsed(); file != "" {
+		watcher, err := watchFile(file)
+		if err != nil {
+			logrus.Errorf("failed to watch the config file, reload with SIGHUP: %v", err)
+		} else {
+			events = watcher
+		}
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-hup:
+			logrus.Info("received SIGHUP, reloading config")
+			reload()
+		case <-events:
+			timer.Reset(debounce)
+		case <-timer.C:
+			logrus.Info("config file changed, reloading config")
+			reload()
+		}
+	}
+}
+
+// reload reloads the config and logs the outcome
+func reload() {
+	if err := Reload(); err != nil {
+		logrus.Errorf("failed to reload config, keeping the current config: %v", err)
+		return
+	}
+	logrus.Info("config reloaded")
+}
+
+// watchFile watches the directory of the file and returns the events of the file
+// the directory is watched, because editors replace the file instead of writing it
+func watchFile(file string) (chan fsnotify.Event, error) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	events := make(chan fsnotify.Event)
+	go func() {
+		for {
+			select {
+			case ev := <-watcher.Events:
+				if filepath.Clean(ev.Name) == file && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					events <- ev
+				}
+			case err := <-watcher.Errors:
+				logrus.Warnf("config watcher: %v", err)
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -3,7 +3,162 @@ window.onload = function () {
     var command = document.getElementById("command");
     var log = document.getElementById("log");
 
-    log.scrollTo(0, log.scrollHeight);
+    var logCursor = "";
+    var logLoading = false;
+    var logDone = false;
+
+    // loads the previous page of the log, the newest page first
+    function loadLog() {
+        if (logLoading || logDone) {
+            return;
+        }
+        logLoading = true;
+
+        let url = document.location.pathname + "api/logs?limit=200";
+        if (logCursor !== "") {
+            url += "&cursor=" + encodeURIComponent(logCursor);
+        }
+
+        fetch(url)
+            .then(function (res) { return res.json(); })
+            .then(function (page) {
+                let first = logCursor === "";
+                let height = log.scrollHeight;
+                let fragment = document.createDocumentFragment();
+                page.entries.forEach(function (entry) {
+                    let item = document.createElement("div");
+                    item.innerText = entry.line;
+                    fragment.appendChild(item);
+                });
+                log.insertBefore(fragment, log.firstChild);
+
+                if (first) {
+                    log.scrollTop = log.scrollHeight;
+                } else {
+                    log.scrollTop += log.scrollHeight - height;
+                }
+
+                logCursor = page.next || "";
+                logDone = logCursor === "";
+                logLoading = false;
+                // a page ends early after searching some files, the next continues with the older
+                if (!logDone && page.entries.length === 0) {
+                    loadLog();
+                }
+            })
+            .catch(function (err) {
+                console.log(err);
+                logLoading = false;
+            });
+    }
+
+    log.onscroll = function () {
+        if (log.scrollTop < 100) {
+            loadLog();
+        }
+    };
+    loadLog();
+
+    var stats = [];
+    var statsWindow = 60 * 60 * 1000;
+
+    function formatValue(value, unit) {
+        if (unit === "B" || unit === "B/s") {
+            let units = ["", "K", "M", "G", "T"];
+            let i = 0;
+            while (value >= 1024 && i < units.length - 1) {
+                value /= 1024;
+                i++;
+            }
+            return value.toFixed(1) + " " + units[i] + unit;
+        }
+        if (unit === "%") {
+            return value.toFixed(1) + unit;
+        }
+        return String(value);
+    }
+
+    function drawStats() {
+        let since = Date.now() - statsWindow;
+        stats = stats.filter(function (s) { return Date.parse(s.time) > since; });
+
+        document.querySelectorAll("#stats .spark").forEach(function (spark) {
+            let key = spark.dataset.key;
+            let canvas = spark.querySelector("canvas");
+            let ctx = canvas.getContext("2d");
+            canvas.width = canvas.clientWidth;
+            canvas.height = canvas.clientHeight;
+            ctx.clearRect(0, 0, canvas.width, canvas.height);
+
+            if (stats.length === 0) {
+                spark.querySelector(".value").innerText = "";
+                return;
+            }
+
+            let max = Math.max.apply(null, stats.map(function (s) { return s[key]; })) || 1;
+            ctx.strokeStyle = "green";
+            ctx.beginPath();
+            stats.forEach(function (s, i) {
+                let x = canvas.width * (Date.parse(s.time) - since) / statsWindow;
+                let y = canvas.height - 1 - (canvas.height - 2) * s[key] / max;
+                if (i === 0) {
+                    ctx.moveTo(x, y);
+                } else {
+                    ctx.lineTo(x, y);
+                }
+            });
+            ctx.stroke();
+
+            spark.querySelector(".value").innerText = formatValue(stats[stats.length - 1][key], spark.dataset.unit);
+        });
+    }
+
+    fetch(document.location.pathname + "api/stats")
+        .then(function (res) { return res.json(); })
+        .then(function (samples) {
+            stats = samples.concat(stats);
+            drawStats();
+        })
+        .catch(function (err) { console.log(err); });
+
+    var status = { reachable: false, sample: [] };
+    var query = { available: false, players: [], plugins: [] };
+
+    function showPlayers() {
+        let players = document.getElementById("players");
+        players.classList.toggle("unreachable", !status.reachable);
+        if (!status.reachable) {
+            players.innerText = "-/-";
+            players.title = status.error || "";
+            return;
+        }
+
+        // the query has the full player list, the ping only a sample
+        let names = query.available ? query.players : status.sample.map(function (p) { return p.name; });
+        let lines = [status.motd, status.version].concat(names);
+        if (query.available && query.plugins.length > 0) {
+            lines.push("", "Plugins: " + query.plugins.join(", "));
+        }
+
+        players.innerText = status.online + "/" + status.max;
+        players.title = lines.join("\n");
+    }
+
+    fetch(document.location.pathname + "api/status")
+        .then(function (res) { return res.json(); })
+        .then(function (s) {
+            status = s;
+            showPlayers();
+        })
+        .catch(function (err) { console.log(err); });
+
+    fetch(document.location.pathname + "api/query")
+        .then(function (res) { return res.json(); })
+        .then(function (q) {
+            query = q;
+            showPlayers();
+        })
+        .catch(function (err) { console.log(err); });
 
     function appendLog(item) {
         var doScroll = log.scrollTop > log.scrollHeight - log.clientHeight - 1;
@@ -42,7 +197,56 @@ window.onload = function () {
         }))
     };
 
+    var history = [];
+    var historyIndex = 0;
+    var draft = "";
+
+    function commonPrefix(values) {
+        return values.reduce(function (prefix, value) {
+            let i = 0;
+            while (i < prefix.length && i < value.length && prefix[i].toLowerCase() === value[i].toLowerCase()) {
+                i++;
+            }
+            return prefix.substring(0, i);
+        });
+    }
+
+    command.onkeydown = function (evt) {
+        switch (evt.key) {
+            case "ArrowUp":
+                if (historyIndex > 0) {
+                    if (historyIndex === history.length) {
+                        draft = command.value;
+                    }
+                    historyIndex--;
+                    command.value = history[historyIndex];
+                }
+                evt.preventDefault();
+                break;
+            case "ArrowDown":
+                if (historyIndex < history.length) {
+                    historyIndex++;
+                    command.value = historyIndex === history.length ? draft : history[historyIndex];
+                }
+                evt.preventDefault();
+                break;
+            case "Tab":
+                send(JSON.stringify({
+                    target: "COMPLETE",
+                    payload: command.value
+                }));
+                evt.preventDefault();
+                break;
+        }
+    };
+
     document.getElementById("form").onsubmit = function () {
+        if (command.value !== "" && history[history.length - 1] !== command.value) {
+            history.push(command.value);
+        }
+        historyIndex = history.length;
+        draft = "";
+
         send(JSON.stringify({
             target: "SERVER",
             payload: command.value
@@ -59,6 +263,12 @@ window.onload = function () {
 
 
         conn = new WebSocket(proto + "//" + document.location.host + document.location.pathname + "ws");
+        conn.onopen = function () {
+            send(JSON.stringify({
+                target: "HISTORY",
+                payload: ""
+            }));
+        };
         conn.onclose = function (evt) {
             let item = document.createElement("div");
             item.classList.add("error");
@@ -90,6 +300,22 @@ window.onload = function () {
                         appendLog(item);
                         break
                     }
+                    case "RESULT": {
+                        let item = document.createElement("div");
+                        if (!msg.payload.success) {
+                            item.classList.add("error");
+                        }
+                        item.innerText = msg.payload.success ? msg.payload.output : msg.payload.error;
+                        appendLog(item);
+                        break
+                    }
+                    case "RESTART": {
+                        let item = document.createElement("div");
+                        item.classList.add("restart");
+                        item.innerText = msg.payload;
+                        appendLog(item);
+                        break
+                    }
                     case "STATE": {
                         let elemStatus = document.getElementById("status");
                         let cl = elemStatus.classList;
@@ -114,6 +340,56 @@ window.onload = function () {
                         }
                         break
                     }
+                    case "PENDING": {
+                        let pending = document.getElementById("pending");
+                        pending.querySelector("span").innerText = msg.payload.join(", ");
+                        pending.hidden = msg.payload.length === 0;
+                        break
+                    }
+                    case "HISTORY": {
+                        history = msg.payload.commands;
+                        historyIndex = history.length;
+                        break
+                    }
+                    case "COMPLETE": {
+                        let suggestions = msg.payload.suggestions;
+                        if (command.value !== msg.payload.input || suggestions.length === 0) {
+                            break
+                        }
+                        if (suggestions.length === 1) {
+                            command.value = suggestions[0] + " ";
+                            break
+                        }
+                        command.value = commonPrefix(suggestions);
+                        let item = document.createElement("div");
+                        item.innerText = suggestions.map(function (s) { return s.split(" ").pop(); }).join("  ");
+                        appendLog(item);
+                        break
+                    }
+                    case "STATS": {
+                        stats.push(msg.payload);
+                        drawStats();
+                        break
+                    }
+                    case "STATUS": {
+                        status = msg.payload;
+                        showPlayers();
+                        break
+                    }
+                    case "QUERY": {
+                        query = msg.payload;
+                        showPlayers();
+                        break
+                    }
+                    case "JOIN":
+                    case "LEAVE":
+                    case "CHAT":
+                    case "DEATH":
+                    case "ADVANCEMENT":
+                    case "SAY":
+                    case "PLAYERS":
+                        // player events are already shown by their log line
+                        break
                     default:
                         console.log("unknown type " + msg.type);
                 }
@@ -0,0 +1,130 @@
+window.onload = function () {
+    var message = document.getElementById("message");
+    var dirs = ["plugins", "mods"];
+
+    function showMessage(text, cls) {
+        message.className = cls || "";
+        message.innerText = text;
+    }
+
+    function request(url, options) {
+        return fetch(base + url, options).then(function (res) {
+            return res.json().then(function (body) {
+                if (!res.ok) {
+                    throw new Error(body.error);
+                }
+                return body;
+            });
+        });
+    }
+
+    function button(value, onclick) {
+        let input = document.createElement("input");
+        input.type = "button";
+        input.value = value;
+        input.onclick = onclick;
+        return input;
+    }
+
+    function jarURL(jar) {
+        return "/api/jars/" + encodeURIComponent(jar.dir) + "/" + encodeURIComponent(jar.file);
+    }
+
+    function row(jar) {
+        let meta = jar.meta || {};
+        let tr = document.createElement("tr");
+        if (!jar.enabled) {
+            tr.className = "offline";
+        }
+
+        [
+            jar.file,
+            jar.error || meta.name || "",
+            meta.version || "",
+            meta.type || "",
+            (meta.authors || []).join(", "),
+            (meta.depends || []).join(", "),
+            jar.staged || ""
+        ].forEach(function (value, i) {
+            let cell = document.createElement("td");
+            cell.innerText = value;
+            if (i == 1 && jar.error) {
+                cell.className = "error";
+            }
+            if (i == 6) {
+                cell.className = "changed";
+            }
+            if (i == 1 && meta.description) {
+                cell.title = meta.description;
+            }
+            tr.appendChild(cell);
+        });
+
+        let action = document.createElement("td");
+        if (jar.staged) {
+            action.appendChild(button("Unstage", function () {
+                change(jarURL(jar) + "/staged", { method: "DELETE" });
+            }));
+        }
+        if (jar.staged != "install") {
+            action.appendChild(button(jar.enabled ? "Disable" : "Enable", function () {
+                change(jarURL(jar) + (jar.enabled ? "/disable" : "/enable"), { method: "POST" });
+            }));
+        }
+        action.appendChild(button("Remove", function () {
+            if (confirm("Remove " + jar.file + "?")) {
+                change(jarURL(jar), { method: "DELETE" });
+            }
+        }));
+        tr.appendChild(action);
+
+        return tr;
+    }
+
+    function load() {
+        request("/api/jars")
+            .then(function (jars) {
+                dirs.forEach(function (dir) {
+                    let tbody = document.querySelector("#" + dir + " tbody");
+                    tbody.innerHTML = "";
+                    jars.filter(function (jar) { return jar.dir == dir; }).forEach(function (jar) {
+                        tbody.appendChild(row(jar));
+                    });
+                });
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    function change(url, options) {
+        request(url, options)
+            .then(function (result) {
+                showMessage(result.output || "Done.");
+                load();
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    dirs.forEach(function (dir) {
+        let form = document.getElementById(dir + "-form");
+        form.onsubmit = function () {
+            let input = form.querySelector("input[type=file]");
+            if (input.files.length == 0) {
+                return false;
+            }
+
+            let file = input.files[0];
+            let body = new FormData();
+            body.append("file", file);
+            input.value = "";
+            showMessage("Uploading " + file.name + " ...");
+            change("/api/jars/" + dir, { method: "POST", body: body });
+            return false;
+        };
+    });
+
+    load();
+};
@@ -0,0 +1,135 @@
+window.onload = function () {
+    var message = document.getElementById("message");
+
+    var lists = [
+        {
+            id: "whitelist",
+            url: "/api/whitelist",
+            key: "name",
+            columns: ["name", "uuid"]
+        },
+        {
+            id: "ops",
+            url: "/api/ops",
+            key: "name",
+            columns: ["name", "uuid", "level"]
+        },
+        {
+            id: "banned-players",
+            url: "/api/bans/players",
+            key: "name",
+            columns: ["name", "uuid", "created", "source", "expires", "reason"]
+        },
+        {
+            id: "banned-ips",
+            url: "/api/bans/ips",
+            key: "ip",
+            columns: ["ip", "created", "source", "expires", "reason"],
+            admin: true
+        }
+    ];
+
+    function showMessage(text, cls) {
+        message.className = cls || "";
+        message.innerText = text;
+    }
+
+    function request(url, options) {
+        return fetch(base + url, options).then(function (res) {
+            return res.json().then(function (body) {
+                if (!res.ok) {
+                    let err = new Error(body.error);
+                    err.status = res.status;
+                    throw err;
+                }
+                return body;
+            });
+        });
+    }
+
+    function loadUsercache() {
+        request("/api/usercache")
+            .then(function (players) {
+                let datalist = document.getElementById("usercache");
+                datalist.innerHTML = "";
+                players.forEach(function (player) {
+                    let option = document.createElement("option");
+                    option.value = player.name;
+                    datalist.appendChild(option);
+                });
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    function load(list) {
+        request(list.url)
+            .then(function (entries) {
+                let tbody = document.querySelector("#" + list.id + " tbody");
+                tbody.innerHTML = "";
+                entries.forEach(function (entry) {
+                    let row = document.createElement("tr");
+                    list.columns.forEach(function (column) {
+                        let cell = document.createElement("td");
+                        cell.innerText = entry[column];
+                        row.appendChild(cell);
+                    });
+
+                    let action = document.createElement("td");
+                    let remove = document.createElement("input");
+                    remove.type = "button";
+                    remove.value = "Remove";
+                    remove.onclick = function () {
+                        change(list, list.url + "/" + encodeURIComponent(entry[list.key]), { method: "DELETE" });
+                    };
+                    action.appendChild(remove);
+                    row.appendChild(action);
+
+                    tbody.appendChild(row);
+                });
+            })
+            .catch(function (err) {
+                // lists only for admins are hidden from the other users
+                if (list.admin && err.status === 403) {
+                    document.getElementById(list.id + "-section").style.display = "none";
+                    return;
+                }
+                showMessage(err.message, "error");
+            });
+    }
+
+    function change(list, url, options) {
+        request(url, options)
+            .then(function (result) {
+                showMessage(result.output || "Done.");
+                // the server writes its files asynchronously
+                setTimeout(function () { load(list); }, 500);
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    lists.forEach(function (list) {
+        let form = document.getElementById(list.id + "-form");
+        form.onsubmit = function () {
+            let body = {};
+            form.querySelectorAll("input[type=text]").forEach(function (input) {
+                body[input.name] = input.value;
+                input.value = "";
+            });
+
+            change(list, list.url, {
+                method: "POST",
+                headers: { "Content-Type": "application/json" },
+                body: JSON.stringify(body)
+            });
+            return false;
+        };
+
+        load(list);
+    });
+
+    loadUsercache();
+};
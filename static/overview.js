@@ -0,0 +1,24 @@
+window.onload = function () {
+    function refresh() {
+        fetch(prefix + "/api/servers")
+            .then(function (res) { return res.json(); })
+            .then(function (servers) {
+                servers.forEach(function (server) {
+                    let row = document.querySelector("#servers tr[data-name=\"" + server.name + "\"]");
+                    if (!row) {
+                        return;
+                    }
+
+                    let state = row.querySelector(".state");
+                    state.className = "state " + server.state;
+                    state.innerText = server.state;
+                    row.querySelector(".players").innerText = server.reachable ? server.players + "/" + server.maxPlayers : server.players;
+                    row.querySelector(".motd").innerText = server.motd;
+                    row.querySelector(".pending").innerText = server.pending.join(", ");
+                });
+            })
+            .catch(function (err) { console.log(err); });
+    }
+
+    setInterval(refresh, 5000);
+};
@@ -0,0 +1,147 @@
+window.onload = function () {
+    var message = document.getElementById("message");
+
+    function showMessage(text, cls) {
+        message.className = cls || "";
+        message.innerText = text;
+    }
+
+    function request(url, options) {
+        return fetch(base + url, options).then(function (res) {
+            return res.json().then(function (body) {
+                if (!res.ok) {
+                    throw new Error(body.error);
+                }
+                return body;
+            });
+        });
+    }
+
+    function formatDuration(seconds) {
+        let h = Math.floor(seconds / 3600);
+        let m = Math.floor(seconds % 3600 / 60);
+        return h + "h " + m + "m";
+    }
+
+    function formatTime(time) {
+        return new Date(time).toLocaleString();
+    }
+
+    function fill(id, rows) {
+        let tbody = document.querySelector("#" + id + " tbody");
+        tbody.innerHTML = "";
+        rows.forEach(function (cells) {
+            let row = document.createElement("tr");
+            cells.forEach(function (value) {
+                let cell = document.createElement("td");
+                if (value instanceof Node) {
+                    cell.appendChild(value);
+                } else {
+                    cell.innerText = value;
+                }
+                row.appendChild(cell);
+            });
+            tbody.appendChild(row);
+        });
+    }
+
+    function loadSessions(player) {
+        request("/api/players/" + encodeURIComponent(player.uuid || player.name) + "/sessions")
+            .then(function (result) {
+                document.getElementById("sessions-title").innerText = "Sessions of " + result.player.name;
+                document.getElementById("sessions-title").hidden = false;
+                document.getElementById("sessions").hidden = false;
+                fill("sessions", result.sessions.slice().reverse().map(function (s) {
+                    return [formatTime(s.join), formatTime(s.leave), formatDuration(s.duration), s.ip || ""];
+                }));
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    var gameModes = ["survival", "creative", "adventure", "spectator"];
+
+    function loadData(player) {
+        let id = encodeURIComponent(player.uuid || player.name);
+        let data = document.getElementById("data");
+
+        request("/api/players/" + id + "/data")
+            .then(function (p) {
+                document.getElementById("data-title").innerText = "Player data of " + player.name;
+                data.hidden = false;
+                fill("data-summary", [
+                    ["Position", p.position.dimension + " " + [p.position.x, p.position.y, p.position.z].map(Math.floor).join(" ")],
+                    ["Spawn", p.spawn ? p.spawn.dimension + " " + [p.spawn.x, p.spawn.y, p.spawn.z].join(" ") : ""],
+                    ["Game mode", gameModes[p.gameMode] || p.gameMode],
+                    ["Health", p.health],
+                    ["Food", p.food],
+                    ["XP", "level " + p.xpLevel + ", total " + p.xpTotal]
+                ]);
+                fill("inventory", p.inventory.map(function (i) { return [i.slot, i.id, i.count]; }));
+                fill("ender-chest", p.enderChest.map(function (i) { return [i.slot, i.id, i.count]; }));
+            })
+            .catch(function (err) {
+                data.hidden = true;
+                showMessage(err.message, "error");
+            });
+
+        request("/api/players/" + id + "/advancements")
+            .then(function (advancements) {
+                fill("advancements", advancements.filter(function (a) { return a.done; }).map(function (a) {
+                    return [a.id];
+                }));
+            })
+            .catch(function (err) { console.log(err); });
+
+        request("/api/players/" + id + "/stats")
+            .then(function (stats) {
+                let rows = [];
+                Object.keys(stats).sort().forEach(function (category) {
+                    Object.keys(stats[category]).sort().forEach(function (key) {
+                        rows.push([category, key, stats[category][key]]);
+                    });
+                });
+                fill("statistics", rows);
+            })
+            .catch(function (err) { console.log(err); });
+    }
+
+    function load() {
+        request("/api/sessions")
+            .then(function (stats) {
+                fill("players", stats.players.map(function (p) {
+                    let link = document.createElement("a");
+                    link.href = "#";
+                    link.innerText = p.name;
+                    link.onclick = function () {
+                        loadSessions(p);
+                        loadData(p);
+                        return false;
+                    };
+                    return [link, p.uuid || "", formatDuration(p.playtime), p.sessions, formatTime(p.firstSeen), formatTime(p.lastSeen)];
+                }));
+                fill("peaks", stats.peaks.slice().reverse().map(function (p) {
+                    return [p.day, p.peak];
+                }));
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    document.getElementById("backfill-form").onsubmit = function () {
+        showMessage("Backfilling...");
+        request("/api/sessions/backfill", { method: "POST" })
+            .then(function (result) {
+                showMessage("Added " + result.added + " sessions.");
+                load();
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+        return false;
+    };
+
+    load();
+};
@@ -0,0 +1,101 @@
+window.onload = function () {
+    var tbody = document.querySelector("#properties tbody");
+    var message = document.getElementById("message");
+    var original = {};
+
+    function showMessage(text, cls) {
+        message.className = cls || "";
+        message.innerText = text;
+    }
+
+    function load() {
+        fetch(base + "/api/properties")
+            .then(function (res) {
+                return res.json().then(function (body) {
+                    if (!res.ok) {
+                        throw new Error(body.error);
+                    }
+                    return body;
+                });
+            })
+            .then(function (props) {
+                tbody.innerHTML = "";
+                original = {};
+                props.forEach(function (prop) {
+                    original[prop.key] = prop.value;
+
+                    let row = document.createElement("tr");
+                    let key = document.createElement("td");
+                    key.innerText = prop.key;
+
+                    let value = document.createElement("td");
+                    let input = document.createElement("input");
+                    input.type = "text";
+                    input.name = prop.key;
+                    input.value = prop.value;
+                    input.oninput = function () {
+                        input.classList.toggle("changed", input.value !== original[prop.key]);
+                    };
+                    value.appendChild(input);
+
+                    let live = document.createElement("td");
+                    if (prop.live) {
+                        live.classList.add("online");
+                        live.innerText = "yes";
+                    }
+
+                    row.appendChild(key);
+                    row.appendChild(value);
+                    row.appendChild(live);
+                    tbody.appendChild(row);
+                });
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+    }
+
+    document.getElementById("form").onsubmit = function () {
+        let changes = {};
+        tbody.querySelectorAll("input").forEach(function (input) {
+            if (input.value !== original[input.name]) {
+                changes[input.name] = input.value;
+            }
+        });
+
+        fetch(base + "/api/properties", {
+            method: "PUT",
+            headers: { "Content-Type": "application/json" },
+            body: JSON.stringify({
+                properties: changes,
+                restart: document.getElementById("restart").checked
+            })
+        })
+            .then(function (res) {
+                return res.json().then(function (body) {
+                    if (!res.ok) {
+                        throw new Error(body.error);
+                    }
+                    return body;
+                });
+            })
+            .then(function (update) {
+                if (update.changed.length === 0) {
+                    showMessage("Nothing changed.");
+                } else if (update.restarted) {
+                    showMessage("Saved " + update.changed.join(", ") + ". The server is restarting.", "restart");
+                } else if (update.restartRequired) {
+                    showMessage("Saved " + update.changed.join(", ") + ". Some changes apply after the next restart.", "restart");
+                } else {
+                    showMessage("Saved " + update.changed.join(", ") + ".");
+                }
+                load();
+            })
+            .catch(function (err) {
+                showMessage(err.message, "error");
+            });
+        return false;
+    };
+
+    load();
+};
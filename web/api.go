@@ -0,0 +1,178 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/audit"
+	"github.com/momper14/msw/logsearch"
+	"github.com/momper14/msw/wrapper"
+	wrappermodel "github.com/momper14/msw/wrapper/model"
+	"github.com/shaj13/go-guardian/v2/auth"
+	"github.com/sirupsen/logrus"
+)
+
+// apiTimeout time to wait for the result of a command
+// must be less than the write timeout of the server
+const apiTimeout = 10 * time.Second
+
+// writeJSON writes v as json response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// writeError writes an error as json response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// registerAPI registers the REST API on the router
+func registerAPI(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	api := router.PathPrefix(prefix + "/api/v1").Subrouter()
+
+	api.HandleFunc("/server/state", func(w http.ResponseWriter, r *http.Request) { serveState(wr, w, r) }).Methods("GET")
+	api.HandleFunc("/server/command", func(w http.ResponseWriter, r *http.Request) { serveCommand(wr, w, r) }).Methods("POST")
+	api.HandleFunc("/server/{action:start|stop|restart}", func(w http.ResponseWriter, r *http.Request) { serveAction(wr, w, r) }).Methods("POST")
+	api.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) { serveLogs(wr, w, r) }).Methods("GET")
+}
+
+// requireRole only allows users with the given role
+func requireRole(role Role, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if roleOf(auth.User(r)) < role {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s role required", role))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// serveAudit serves the audit log
+// filtered by user and time range in RFC 3339
+func serveAudit(w http.ResponseWriter, r *http.Request) {
+	var (
+		f   = audit.Filter{Instance: r.URL.Query().Get("instance"), User: r.URL.Query().Get("user")}
+		err error
+	)
+
+	if val := r.URL.Query().Get("since"); val != "" {
+		if f.Since, err = time.Parse(time.RFC3339, val); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if val := r.URL.Query().Get("until"); val != "" {
+		if f.Until, err = time.Parse(time.RFC3339, val); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	entries, err := audit.Query(f)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// serveState serves the current state of the Minecraft Server
+func serveState(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"state": wr.CurrentState().String()})
+}
+
+// serveAction executes a wrapper action like start, stop and restart
+func serveAction(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	execute(wr, w, r, &wrappermodel.Command{
+		Target:  wrappermodel.TargetWrapper,
+		Payload: mux.Vars(r)["action"],
+	})
+}
+
+// serveCommand executes the command of the request body
+// the target defaults to the Minecraft Server
+func serveCommand(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	command := new(wrappermodel.Command)
+	if err := json.NewDecoder(r.Body).Decode(command); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if command.Target == 0 {
+		command.Target = wrappermodel.TargetServer
+	}
+
+	execute(wr, w, r, command)
+}
+
+// execute executes the command if the user is authorized and writes its result
+// if it takes too long, it's accepted and runs in the background
+// if it can't be queued, it's rejected as unavailable
+func execute(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request, command *wrappermodel.Command) {
+	command.User = auth.User(r).GetUserName()
+	command.RemoteAddr = r.RemoteAddr
+	command.Instance = wr.Name()
+
+	if err := authorize(roleOf(auth.User(r)), command); err != nil {
+		logrus.Warn(err)
+		audit.RecordCommand(command, "", err)
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	res, err := wr.Execute(command, apiTimeout)
+	if err == wrapper.ErrBusy {
+		writeJSON(w, http.StatusServiceUnavailable, wrappermodel.Result{ID: command.ID, Success: false, Error: err.Error()})
+		return
+	}
+	if err == wrapper.ErrTimeout {
+		writeJSON(w, http.StatusAccepted, wrappermodel.Result{ID: command.ID, Success: true, Output: "pending"})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !res.Success {
+		status = http.StatusUnprocessableEntity
+	}
+	writeJSON(w, status, redactResult(roleOf(auth.User(r)), *res))
+}
+
+// serveLogs serves the last lines of the log
+// the number of lines is set by tail, defaults to 100 and is at most maxLogLimit
+func serveLogs(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	tail := 100
+	if val := r.URL.Query().Get("tail"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 || n > maxLogLimit {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid tail %q, must be between 0 and %d", val, maxLogLimit))
+			return
+		}
+		tail = n
+	}
+
+	lines := []string{}
+	if tail > 0 {
+		page, err := serverLogs(wr).Search(logsearch.Query{Limit: tail, Redact: redactLines(roleOf(auth.User(r)))})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, e := range page.Entries {
+			lines = append(lines, e.Line)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, lines)
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheEntry value of the cache with its expiry
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// authCache caches authentication decisions
+// implements auth.Cache
+type authCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[interface{}]cacheEntry
+}
+
+// newAuthCache initialises a new authCache
+func newAuthCache(ttl time.Duration) *authCache {
+	return &authCache{
+		ttl:     ttl,
+		entries: make(map[interface{}]cacheEntry),
+	}
+}
+
+// Load returns the value of key
+func (c *authCache) Load(key interface{}) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+// Store sets the value of key
+func (c *authCache) Store(key interface{}, value interface{}) {
+	c.StoreWithTTL(key, value, c.ttl)
+}
+
+// StoreWithTTL sets the value of key with a custom ttl
+func (c *authCache) StoreWithTTL(key interface{}, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{value: value, expires: time.Now().Add(ttl)}
+}
+
+// Delete deletes the value of key
+func (c *authCache) Delete(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Clear deletes all values
+func (c *authCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[interface{}]cacheEntry)
+}
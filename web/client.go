@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/shaj13/go-guardian/v2/auth"
 	"github.com/sirupsen/logrus"
 )
 
@@ -41,6 +42,10 @@ type Client struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The authenticated user and its remote address.
+	user auth.Info
+	addr string
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -67,7 +72,7 @@ func (c *Client) readPump() {
 			break
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		c.hub.SendCommand(message)
+		c.hub.SendCommand(c, message)
 	}
 }
 
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/wrapper"
+	wrappermodel "github.com/momper14/msw/wrapper/model"
+	"github.com/shaj13/go-guardian/v2/auth"
+)
+
+// registerConsole registers the API of the command history and completion
+func registerConsole(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/api/history", func(w http.ResponseWriter, r *http.Request) { serveHistory(wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/complete", func(w http.ResponseWriter, r *http.Request) { serveComplete(wr, w, r) }).Methods("GET")
+}
+
+// complete answers a COMPLETE request
+// only suggestions the user may run are kept
+func complete(wr *wrapper.Wrapper, role Role, request *wrappermodel.Command) *wrappermodel.Message {
+	suggestions := make([]string, 0)
+	for _, s := range wr.Complete(request.Payload) {
+		if authorize(role, &wrappermodel.Command{Target: wrappermodel.TargetServer, Payload: s}) == nil {
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	return &wrappermodel.Message{
+		Type: wrappermodel.TypeComplete,
+		Payload: wrappermodel.Completion{
+			ID:          request.ID,
+			Input:       request.Payload,
+			Suggestions: suggestions,
+		},
+	}
+}
+
+// history answers a HISTORY request with the history of the user
+func history(wr *wrapper.Wrapper, request *wrappermodel.Command) *wrappermodel.Message {
+	commands, err := wr.History(request.User)
+	if err != nil {
+		return request.Result("", err)
+	}
+
+	return &wrappermodel.Message{
+		Type: wrappermodel.TypeHistory,
+		Payload: wrappermodel.History{
+			ID:       request.ID,
+			Commands: commands,
+		},
+	}
+}
+
+// serveHistory serves the command history of the user
+func serveHistory(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	commands, err := wr.History(auth.User(r).GetUserName())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, wrappermodel.History{Commands: commands})
+}
+
+// serveComplete serves the suggestions completing the input
+func serveComplete(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	request := &wrappermodel.Command{Payload: r.URL.Query().Get("input")}
+	writeJSON(w, http.StatusOK, complete(wr, roleOf(auth.User(r)), request).Payload)
+}
@@ -2,16 +2,15 @@ package web
 
 import (
 	"context"
-	"fmt"
-	"io/ioutil"
+	"crypto"
+	_ "crypto/sha256" // registers the hash of the auth cache
 	"net/http"
-	"os"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/momper14/msw/reload"
 	"github.com/momper14/msw/wrapper"
 	"github.com/shaj13/go-guardian/v2/auth"
 	"github.com/shaj13/go-guardian/v2/auth/strategies/basic"
@@ -25,6 +24,9 @@ import (
 var (
 	healthy  int32
 	strategy union.Union
+	cache    *authCache
+	// prefix of all routes, changes require restarting the MSW
+	webPrefix string
 )
 
 // inits viper
@@ -33,68 +35,105 @@ func init() {
 	viper.SetDefault("web.prefix", "")
 	viper.SetDefault("web.user", "user")
 	viper.SetDefault("web.password", "password")
+	viper.SetDefault("web.usersfile", "")
+	viper.SetDefault("web.permissions.viewer.wrapper", []string{})
+	viper.SetDefault("web.permissions.viewer.server", []string{})
+	viper.SetDefault("web.permissions.operator.wrapper", []string{"start", "stop", "restart"})
+	viper.SetDefault("web.permissions.operator.server", []string{"list", "say", "tell", "msg", "kick", "whitelist", "save-all"})
 }
 
-// init Go Guardian
+// init users and permissions
 func init() {
-	strategy = union.New(basic.New(validateUser))
-}
-
-func validateUser(ctx context.Context, r *http.Request, userName, password string) (auth.Info, error) {
-	if userName == viper.GetString("web.user") && password == viper.GetString("web.password") {
-		return auth.NewDefaultUser(userName, "0", nil, nil), nil
+	var err error
+	if creds, err = loadCredentials(); err != nil {
+		logrus.Fatal(err)
 	}
+}
 
-	return nil, fmt.Errorf("Invalid credentials")
+// init Go Guardian
+func init() {
+	cache = newAuthCache(5 * time.Minute)
+	strategy = union.New(basic.NewCached(validateUser, cache, basic.SetHash(crypto.SHA256)))
 }
 
 func middleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	_, _, err := strategy.AuthenticateRequest(r)
+	_, info, err := strategy.AuthenticateRequest(r)
 	if err != nil {
 		logrus.Warn(err)
 		w.Header().Set("WWW-Authenticate", "Basic realm=\"Authorization Required\"")
 		http.Error(w, "Not Authorized", http.StatusUnauthorized)
 		return
 	}
-	next.ServeHTTP(w, r)
+	next.ServeHTTP(w, auth.RequestWithUser(info, r))
 }
 
 // Controller to controll the web server
 type Controller struct {
 	Server *http.Server
-	Hub    *Hub
+	Hubs   map[string]*Hub
 }
 
-// latestLog reads the latest Minecraft Server log
-func latestLog() []string {
-	file := fmt.Sprintf("%s/%s", viper.GetString("mc.workingdir"), viper.GetString("log.filename"))
-	content, err := ioutil.ReadFile(file)
-	if os.IsNotExist(err) {
-		return make([]string, 0)
-	}
-	if err != nil {
-		logrus.Error(err)
-		return make([]string, 0)
-	}
+// ServerInfo an instance with its state
+type ServerInfo struct {
+	Name       string   `json:"name"`
+	State      string   `json:"state"`
+	Players    int      `json:"players"`
+	MaxPlayers int      `json:"maxPlayers"`
+	Reachable  bool     `json:"reachable"`
+	MOTD       string   `json:"motd"`
+	Base       string   `json:"base"`
+	Pending    []string `json:"pending"`
+}
 
-	return strings.Split(string(content), "\n")
+// serverInfos returns the infos of the instances
+func serverInfos(wrappers []*wrapper.Wrapper) []ServerInfo {
+	infos := make([]ServerInfo, 0, len(wrappers))
+	for _, wr := range wrappers {
+		status := wr.Status()
+		infos = append(infos, ServerInfo{
+			Name:       wr.Name(),
+			State:      wr.CurrentState().String(),
+			Players:    len(wr.Players()),
+			MaxPlayers: status.Max,
+			Reachable:  status.Reachable,
+			MOTD:       status.MOTD,
+			Base:       instanceBase(wr.Name()),
+			Pending:    wr.Pending(),
+		})
+	}
+	return infos
+}
 
+// instanceBase returns the path all routes of the instance start with
+func instanceBase(name string) string {
+	return webPrefix + "/servers/" + name
 }
 
 // NewController initialises a new web controller
-func NewController(wrapper *wrapper.Wrapper) *Controller {
-	c := Controller{}
-	prefix := viper.GetString("web.prefix")
+// the routes of each instance are below /servers/{name}
+func NewController(wrappers []*wrapper.Wrapper) *Controller {
+	c := Controller{Hubs: make(map[string]*Hub)}
+	webPrefix = viper.GetString("web.prefix")
+	mswLogs = newMSWLogs()
+	prefix := webPrefix
 	logrus.Infof("using prefix %s", prefix)
 
-	c.Hub = NewHub()
-	c.Hub.Subscribe(wrapper)
-
 	router := mux.NewRouter()
-	router.HandleFunc(prefix+"/", func(w http.ResponseWriter, r *http.Request) { serveHome(wrapper, w, r) }).Methods("GET")
+	for _, wr := range wrappers {
+		registerInstance(router, wr, c.newHub(wr))
+	}
+	if len(wrappers) == 1 {
+		registerAliases(router, prefix, wrappers[0], c.Hubs[wrappers[0].Name()])
+	}
+
+	router.HandleFunc(prefix+"/", func(w http.ResponseWriter, r *http.Request) { serveOverview(wrappers, w, r) }).Methods("GET")
 	router.PathPrefix(prefix + "/static/").Handler(http.StripPrefix(prefix+"/static/", http.FileServer(http.Dir("./static")))).Methods("GET")
-	router.HandleFunc(prefix+"/ws", func(w http.ResponseWriter, r *http.Request) { ServeWs(c.Hub, wrapper, w, r) }).Methods("GET")
-	router.Handle(prefix+"/healthz", healthz()).Methods("GET")
+	router.Handle(prefix+"/healthz", healthz(wrappers)).Methods("GET")
+	router.Handle(prefix+"/readyz", readyz(wrappers)).Methods("GET")
+	router.Handle(prefix+"/metrics", metricsHandler(wrappers, c.Hubs)).Methods("GET")
+	router.HandleFunc(prefix+"/api/servers", func(w http.ResponseWriter, r *http.Request) { serveServers(wrappers, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/audit", requireRole(RoleAdmin, serveAudit)).Methods("GET")
+	router.HandleFunc(prefix+"/api/logs", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { serveLogSearch(mswLogs, w, r) })).Methods("GET")
 
 	n := negroni.Classic()
 	//n.Use(auth.Basic(viper.GetString("web.user"), viper.GetString("web.password")))
@@ -109,12 +148,75 @@ func NewController(wrapper *wrapper.Wrapper) *Controller {
 		MaxHeaderBytes: 1 << 20,
 	}
 
+	reload.Register("web", c.reload)
+
 	return &c
 }
 
+// reload reloads the users and permissions of the reread config
+// changes of the address and prefix require restarting the MSW
+func (c *Controller) reload() (func(), error) {
+	apply, err := reloadCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		apply()
+		if viper.GetString("web.addr") != c.Server.Addr || viper.GetString("web.prefix") != webPrefix {
+			logrus.Warn("changes of web.addr and web.prefix require restarting the MSW")
+		}
+	}, nil
+}
+
+// newHub creates the Hub of the instance
+func (c *Controller) newHub(wr *wrapper.Wrapper) *Hub {
+	hub := NewHub(wr.Name())
+	hub.Subscribe(wr)
+	c.Hubs[wr.Name()] = hub
+	return hub
+}
+
+// registerInstance registers the pages, websocket and API of the instance
+func registerInstance(router *mux.Router, wr *wrapper.Wrapper, hub *Hub) {
+	base := instanceBase(wr.Name())
+
+	router.Handle(base, http.RedirectHandler(base+"/", http.StatusMovedPermanently)).Methods("GET")
+	router.HandleFunc(base+"/", func(w http.ResponseWriter, r *http.Request) { serveHome(wr, w, r) }).Methods("GET")
+	router.HandleFunc(base+"/ws", func(w http.ResponseWriter, r *http.Request) { ServeWs(hub, wr, w, r) }).Methods("GET")
+	router.HandleFunc(base+"/api/players", func(w http.ResponseWriter, r *http.Request) { servePlayers(wr, w, r) }).Methods("GET")
+	router.Handle(base+"/readyz", readyz([]*wrapper.Wrapper{wr})).Methods("GET")
+	router.HandleFunc(base+"/api/status", func(w http.ResponseWriter, r *http.Request) { serveStatus(wr, w, r) }).Methods("GET")
+	router.HandleFunc(base+"/api/query", func(w http.ResponseWriter, r *http.Request) { serveQuery(wr, w, r) }).Methods("GET")
+	router.HandleFunc(base+"/api/stats", func(w http.ResponseWriter, r *http.Request) { serveStats(wr, w, r) }).Methods("GET")
+	router.HandleFunc(base+"/api/logs", func(w http.ResponseWriter, r *http.Request) { serveLogSearch(serverLogs(wr), w, r) }).Methods("GET")
+	registerAPI(router, base, wr)
+	registerSchedules(router, base, wr)
+	registerProperties(router, base, wr)
+	registerLists(router, base, wr)
+	registerConsole(router, base, wr)
+	registerSessions(router, base, wr)
+	registerWorld(router, base, wr)
+	registerJars(router, base, wr)
+}
+
+// registerAliases registers the routes of the instance without its namespace
+// keeps the routes from before multiple instances working for a single instance
+func registerAliases(router *mux.Router, prefix string, wr *wrapper.Wrapper, hub *Hub) {
+	router.HandleFunc(prefix+"/ws", func(w http.ResponseWriter, r *http.Request) { ServeWs(hub, wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/players", func(w http.ResponseWriter, r *http.Request) { servePlayers(wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/stats", func(w http.ResponseWriter, r *http.Request) { serveStats(wr, w, r) }).Methods("GET")
+	registerAPI(router, prefix, wr)
+	registerSchedules(router, prefix, wr)
+	registerProperties(router, prefix, wr)
+	registerLists(router, prefix, wr)
+}
+
 // Run starts the web server
 func (c *Controller) Run() {
-	go c.Hub.Run()
+	for _, hub := range c.Hubs {
+		go hub.Run()
+	}
 	go func() {
 		if err := c.Server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logrus.Error(err)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/momper14/msw/audit"
 	"github.com/momper14/msw/wrapper"
 	wrappermodel "github.com/momper14/msw/wrapper/model"
 	"github.com/sirupsen/logrus"
@@ -11,20 +12,33 @@ import (
 
 // Hub maintains the set of active clients and broadcasts messages to the clients.
 type Hub struct {
+	instance   string
+	wrapper    *wrapper.Wrapper
 	clients    map[*Client]bool
 	msw        chan *wrappermodel.Message
+	replies    chan *reply
 	register   chan *Client
 	unregister chan *Client
 	command    chan *wrappermodel.Command
+	metrics    *hubMetrics
 }
 
-// NewHub initialises a new Hub
-func NewHub() *Hub {
+// reply is a message for a single client
+type reply struct {
+	client  *Client
+	message *wrappermodel.Message
+}
+
+// NewHub initialises a new Hub for the instance
+func NewHub(instance string) *Hub {
 	return &Hub{
+		instance:   instance,
 		msw:        make(chan *wrappermodel.Message),
+		replies:    make(chan *reply, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
+		metrics:    newHubMetrics(instance),
 	}
 }
 
@@ -34,27 +48,51 @@ func (h *Hub) Run() {
 		select {
 		case client := <-h.register:
 			h.clients[client] = true
+			h.metrics.clients.Set(float64(len(h.clients)))
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
+				h.metrics.clients.Set(float64(len(h.clients)))
 			}
 		case message := <-h.msw:
-			json, _ := json.Marshal(message)
+			// the message is encoded once per role as the roles see different messages
+			encoded := make(map[Role][]byte)
 			for client := range h.clients {
+				role := roleOf(client.user)
+				data, ok := encoded[role]
+				if !ok {
+					data, _ = json.Marshal(redact(role, message))
+					encoded[role] = data
+				}
 				select {
-				case client.send <- json:
+				case client.send <- data:
 				default:
 					close(client.send)
 					delete(h.clients, client)
+					h.metrics.dropped.Inc()
+					h.metrics.clients.Set(float64(len(h.clients)))
 				}
 			}
+		case r := <-h.replies:
+			if _, ok := h.clients[r.client]; !ok {
+				break
+			}
+			json, _ := json.Marshal(redact(roleOf(r.client.user), r.message))
+			select {
+			case r.client.send <- json:
+			default:
+				close(r.client.send)
+				delete(h.clients, r.client)
+				h.metrics.dropped.Inc()
+				h.metrics.clients.Set(float64(len(h.clients)))
+			}
 		}
 	}
 }
 
-// SendCommand sends a command to the MSW
-func (h *Hub) SendCommand(c []byte) {
+// SendCommand sends a command of the client to the MSW
+func (h *Hub) SendCommand(client *Client, c []byte) {
 	var cs = new(wrappermodel.Command)
 
 	fmt.Printf("%s\n", c)
@@ -64,10 +102,34 @@ func (h *Hub) SendCommand(c []byte) {
 		return
 	}
 
+	cs.User = client.user.GetUserName()
+	cs.RemoteAddr = client.addr
+	cs.Instance = h.instance
+	cs.Reply = func(m *wrappermodel.Message) {
+		h.replies <- &reply{client: client, message: m}
+	}
+
+	switch cs.Target {
+	case wrappermodel.TargetComplete:
+		cs.Reply(complete(h.wrapper, roleOf(client.user), cs))
+		return
+	case wrappermodel.TargetHistory:
+		cs.Reply(history(h.wrapper, cs))
+		return
+	}
+
+	if err := authorize(roleOf(client.user), cs); err != nil {
+		logrus.Warn(err)
+		audit.RecordCommand(cs, "", err)
+		cs.Reply(cs.Result("", err))
+		return
+	}
+
 	h.command <- cs
 }
 
 // Subscribe subscribes to the MSW
 func (h *Hub) Subscribe(w *wrapper.Wrapper) {
+	h.wrapper = w
 	h.command = w.Subscribe(h.msw)
 }
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/wrapper"
+	wrappermodel "github.com/momper14/msw/wrapper/model"
+)
+
+// maxJarSize maximum size of an uploaded jar
+const maxJarSize = 256 << 20
+
+// registerJars registers the API and page to manage the plugins and mods
+// changes are staged until the next start of the Minecraft Server
+func registerJars(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/jars", func(w http.ResponseWriter, r *http.Request) { servePage("template/jars.html", wr, w) }).Methods("GET")
+
+	router.HandleFunc(prefix+"/api/jars", func(w http.ResponseWriter, r *http.Request) { serveJars(wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/jars/{dir}", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { uploadJar(wr, w, r) })).Methods("POST")
+	router.HandleFunc(prefix+"/api/jars/{dir}/{file}/enable", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		changeJar(w, r, wr.EnableJar)
+	})).Methods("POST")
+	router.HandleFunc(prefix+"/api/jars/{dir}/{file}/disable", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		changeJar(w, r, wr.DisableJar)
+	})).Methods("POST")
+	router.HandleFunc(prefix+"/api/jars/{dir}/{file}", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		changeJar(w, r, wr.RemoveJar)
+	})).Methods("DELETE")
+	router.HandleFunc(prefix+"/api/jars/{dir}/{file}/staged", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		changeJar(w, r, wr.UnstageJar)
+	})).Methods("DELETE")
+}
+
+// serveJars serves the plugins and mods with their staged changes
+func serveJars(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	list, err := wr.Jars()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// uploadJar stages the jar of the multipart field file
+func uploadJar(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxJarSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	err = wr.UploadJar(mux.Vars(r)["dir"], filepath.Base(header.Filename), file)
+	serveJarChange(w, err)
+}
+
+// changeJar applies the change to the jar of the request
+func changeJar(w http.ResponseWriter, r *http.Request, change func(dir, file string) error) {
+	vars := mux.Vars(r)
+	serveJarChange(w, change(vars["dir"], vars["file"]))
+}
+
+// serveJarChange serves the result of a change of a jar
+func serveJarChange(w http.ResponseWriter, err error) {
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, wrappermodel.Result{Success: true, Output: "staged until the next start"})
+	case errors.Is(err, wrapper.ErrInvalidJar):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, wrapper.ErrUnknownJar):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, wrapper.ErrServerStarting), errors.Is(err, wrapper.ErrJarConflict):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
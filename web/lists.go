@@ -0,0 +1,156 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/audit"
+	"github.com/momper14/msw/wrapper"
+	wrappermodel "github.com/momper14/msw/wrapper/model"
+	"github.com/shaj13/go-guardian/v2/auth"
+	"github.com/sirupsen/logrus"
+)
+
+// listRequest body to add an entry to a player list
+type listRequest struct {
+	Name   string `json:"name"`
+	IP     string `json:"ip"`
+	Reason string `json:"reason"`
+}
+
+// registerLists registers the API and page to manage the whitelist, ops and bans
+// the banned ips are only listed to admins
+func registerLists(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/lists", func(w http.ResponseWriter, r *http.Request) { serveListsPage(wr, w, r) }).Methods("GET")
+
+	router.HandleFunc(prefix+"/api/usercache", func(w http.ResponseWriter, r *http.Request) {
+		list, err := wr.Usercache()
+		serveList(w, list, err)
+	}).Methods("GET")
+	router.HandleFunc(prefix+"/api/whitelist", func(w http.ResponseWriter, r *http.Request) {
+		list, err := wr.Whitelist()
+		serveList(w, list, err)
+	}).Methods("GET")
+	router.HandleFunc(prefix+"/api/ops", func(w http.ResponseWriter, r *http.Request) {
+		list, err := wr.Ops()
+		serveList(w, list, err)
+	}).Methods("GET")
+	router.HandleFunc(prefix+"/api/bans/players", func(w http.ResponseWriter, r *http.Request) {
+		list, err := wr.BannedPlayers()
+		serveList(w, list, err)
+	}).Methods("GET")
+	router.HandleFunc(prefix+"/api/bans/ips", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {
+		list, err := wr.BannedIPs()
+		serveList(w, list, err)
+	})).Methods("GET")
+
+	router.HandleFunc(prefix+"/api/whitelist", func(w http.ResponseWriter, r *http.Request) {
+		addToList(wr, w, r, func(req listRequest) (string, func() (string, error)) {
+			return "whitelist add " + req.Name, func() (string, error) { return wr.WhitelistAdd(req.Name) }
+		})
+	}).Methods("POST")
+	router.HandleFunc(prefix+"/api/whitelist/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		changeList(wr, w, r, "whitelist remove "+name, func() (string, error) { return wr.WhitelistRemove(name) })
+	}).Methods("DELETE")
+
+	router.HandleFunc(prefix+"/api/ops", func(w http.ResponseWriter, r *http.Request) {
+		addToList(wr, w, r, func(req listRequest) (string, func() (string, error)) {
+			return "op " + req.Name, func() (string, error) { return wr.Op(req.Name) }
+		})
+	}).Methods("POST")
+	router.HandleFunc(prefix+"/api/ops/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		changeList(wr, w, r, "deop "+name, func() (string, error) { return wr.Deop(name) })
+	}).Methods("DELETE")
+
+	router.HandleFunc(prefix+"/api/bans/players", func(w http.ResponseWriter, r *http.Request) {
+		addToList(wr, w, r, func(req listRequest) (string, func() (string, error)) {
+			return "ban " + req.Name, func() (string, error) { return wr.Ban(req.Name, req.Reason) }
+		})
+	}).Methods("POST")
+	router.HandleFunc(prefix+"/api/bans/players/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := mux.Vars(r)["name"]
+		changeList(wr, w, r, "pardon "+name, func() (string, error) { return wr.Pardon(name) })
+	}).Methods("DELETE")
+
+	router.HandleFunc(prefix+"/api/bans/ips", func(w http.ResponseWriter, r *http.Request) {
+		addToList(wr, w, r, func(req listRequest) (string, func() (string, error)) {
+			return "ban-ip " + req.IP, func() (string, error) { return wr.BanIP(req.IP, req.Reason) }
+		})
+	}).Methods("POST")
+	router.HandleFunc(prefix+"/api/bans/ips/{ip}", func(w http.ResponseWriter, r *http.Request) {
+		ip := mux.Vars(r)["ip"]
+		changeList(wr, w, r, "pardon-ip "+ip, func() (string, error) { return wr.PardonIP(ip) })
+	}).Methods("DELETE")
+}
+
+// serveListsPage serves the page to manage the whitelist, ops and bans
+func serveListsPage(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	servePage("template/lists.html", wr, w)
+}
+
+// serveList serves a player list
+func serveList(w http.ResponseWriter, list interface{}, err error) {
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// addToList decodes the request body and applies the change built of it
+func addToList(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request, build func(listRequest) (string, func() (string, error))) {
+	var req listRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload, change := build(req)
+	changeList(wr, w, r, payload, change)
+}
+
+// changeList applies the change if the user may run the equivalent server command
+// the change is recorded in the audit log
+func changeList(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request, payload string, change func() (string, error)) {
+	command := &wrappermodel.Command{
+		Target:     wrappermodel.TargetServer,
+		Payload:    payload,
+		User:       auth.User(r).GetUserName(),
+		RemoteAddr: r.RemoteAddr,
+		Instance:   wr.Name(),
+	}
+
+	if err := authorize(roleOf(auth.User(r)), command); err != nil {
+		logrus.Warn(err)
+		audit.RecordCommand(command, "", err)
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+
+	output, err := change()
+	audit.RecordCommand(command, output, err)
+	if err != nil {
+		writeError(w, listErrorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, wrappermodel.Result{Success: true, Output: output})
+}
+
+// listErrorStatus returns the http status of an error changing a player list
+func listErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, wrapper.ErrInvalidEntry):
+		return http.StatusBadRequest
+	case errors.Is(err, wrapper.ErrUnknownPlayer), errors.Is(err, wrapper.ErrNotListed):
+		return http.StatusNotFound
+	case errors.Is(err, wrapper.ErrServerBusy):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
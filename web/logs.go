@@ -0,0 +1,120 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/logsearch"
+	"github.com/momper14/msw/wrapper"
+	"github.com/shaj13/go-guardian/v2/auth"
+	"github.com/spf13/viper"
+)
+
+// limits of the entries of a page of the logs
+const (
+	defaultLogLimit = 100
+	maxLogLimit     = 1000
+)
+
+var (
+	logSourcesMu sync.Mutex
+	// logSources the sources of the logs of the Minecraft Servers by directory
+	// kept, so the indexes of the rotated logs are reused
+	logSources = make(map[string]*logsearch.Source)
+	// mswLogs the source of the logs of the MSW
+	mswLogs *logsearch.Source
+)
+
+// newMSWLogs initialises the source of the logs of the MSW
+// the log settings are only applied on restart of the MSW
+func newMSWLogs() *logsearch.Source {
+	file := viper.GetString("log.filename")
+	return logsearch.NewSource(filepath.Dir(file), filepath.Base(file), logsearch.MSWFormat(viper.GetString("log.timestampformat")))
+}
+
+// serverLogs returns the source of the logs of the Minecraft Server
+// the working directory can change on restart, so the source is looked up every time
+func serverLogs(wr *wrapper.Wrapper) *logsearch.Source {
+	dir := filepath.Join(wr.Workingdir(), "logs")
+
+	logSourcesMu.Lock()
+	defer logSourcesMu.Unlock()
+
+	source, ok := logSources[dir]
+	if !ok {
+		source = logsearch.NewSource(dir, "latest.log", logsearch.ServerFormat())
+		logSources[dir] = source
+	}
+	return source
+}
+
+// parseLogQuery parses the filters and paging of the request
+// since and until in RFC 3339, level as comma separated list, regex, cursor and limit
+func parseLogQuery(r *http.Request) (logsearch.Query, error) {
+	var (
+		values = r.URL.Query()
+		q      = logsearch.Query{Cursor: values.Get("cursor"), Limit: defaultLogLimit}
+		err    error
+	)
+
+	if val := values.Get("since"); val != "" {
+		if q.Since, err = time.Parse(time.RFC3339, val); err != nil {
+			return q, err
+		}
+	}
+	if val := values.Get("until"); val != "" {
+		if q.Until, err = time.Parse(time.RFC3339, val); err != nil {
+			return q, err
+		}
+	}
+	for _, val := range values["level"] {
+		for _, level := range strings.Split(val, ",") {
+			if level = strings.TrimSpace(level); level != "" {
+				q.Levels = append(q.Levels, level)
+			}
+		}
+	}
+	if val := values.Get("regex"); val != "" {
+		if q.Regexp, err = regexp.Compile(val); err != nil {
+			return q, err
+		}
+	}
+	if val := values.Get("limit"); val != "" {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 || n > maxLogLimit {
+			return q, fmt.Errorf("invalid limit %q, must be between 1 and %d", val, maxLogLimit)
+		}
+		q.Limit = n
+	}
+
+	return q, nil
+}
+
+// serveLogSearch serves the page of the entries of the source matching the query
+// the ips in the lines are hidden unless the user is admin, also from the regex
+func serveLogSearch(source *logsearch.Source, w http.ResponseWriter, r *http.Request) {
+	q, err := parseLogQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	q.Redact = redactLines(roleOf(auth.User(r)))
+
+	page, err := source.Search(q)
+	if errors.Is(err, logsearch.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/momper14/msw/wrapper"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// hubMetrics metrics of the Hub
+type hubMetrics struct {
+	clients prometheus.Gauge
+	dropped prometheus.Counter
+}
+
+// newHubMetrics initialises the metrics of a Hub labeled with the instance as server
+func newHubMetrics(instance string) *hubMetrics {
+	labels := prometheus.Labels{"server": instance}
+
+	return &hubMetrics{
+		clients: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "msw_websocket_clients",
+			Help:        "Number of connected websocket clients.",
+			ConstLabels: labels,
+		}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "msw_websocket_messages_dropped_total",
+			Help:        "Number of messages dropped because the client was too slow.",
+			ConstLabels: labels,
+		}),
+	}
+}
+
+// metricsHandler serves the metrics of the wrappers, their hubs and the process in Prometheus format
+func metricsHandler(wrappers []*wrapper.Wrapper, hubs map[string]*Hub) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+
+	for _, wr := range wrappers {
+		hub := hubs[wr.Name()]
+		registry.MustRegister(wr, hub.metrics.clients, hub.metrics.dropped)
+	}
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -4,10 +4,12 @@ import (
 	"html/template"
 	"net/http"
 	"sync/atomic"
+	"time"
 
 	"github.com/momper14/msw/wrapper"
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/shaj13/go-guardian/v2/auth"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
 // ServeWs handles websocket requests from the peer.
@@ -17,7 +19,7 @@ func ServeWs(hub *Hub, wrapper *wrapper.Wrapper, w http.ResponseWriter, r *http.
 		logrus.Error(err)
 		return
 	}
-	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256)}
+	client := &Client{hub: hub, conn: conn, send: make(chan []byte, 256), user: auth.User(r), addr: r.RemoteAddr}
 	client.hub.register <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
@@ -26,16 +28,90 @@ func ServeWs(hub *Hub, wrapper *wrapper.Wrapper, w http.ResponseWriter, r *http.
 	go client.readPump()
 }
 
-func healthz() http.Handler {
+// servePlayers serves the players online
+// their ips are left out unless the user is admin
+func servePlayers(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, redactPlayers(roleOf(auth.User(r)), wr.Players()))
+}
+
+// serveStats serves the sampled resource usage
+// filtered by since in RFC 3339
+func serveStats(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if val := r.URL.Query().Get("since"); val != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, val); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, wr.Stats(since))
+}
+
+// Health the health of an instance
+type Health struct {
+	Name   string       `json:"name"`
+	State  string       `json:"state"`
+	Ready  bool         `json:"ready"`
+	Status model.Status `json:"status"`
+}
+
+// healths returns the health of the instances
+func healths(wrappers []*wrapper.Wrapper) []Health {
+	hs := make([]Health, 0, len(wrappers))
+	for _, wr := range wrappers {
+		hs = append(hs, Health{
+			Name:   wr.Name(),
+			State:  wr.CurrentState().String(),
+			Ready:  wr.Ready(),
+			Status: wr.Status(),
+		})
+	}
+	return hs
+}
+
+// healthz serves the liveness of the MSW with the health of the instances
+// the status code only depends on the web server, not on the Minecraft Servers
+func healthz(wrappers []*wrapper.Wrapper) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := http.StatusServiceUnavailable
 		if atomic.LoadInt32(&healthy) == 1 {
-			w.WriteHeader(http.StatusNoContent)
-			return
+			code = http.StatusOK
+		}
+		writeJSON(w, code, healths(wrappers))
+	})
+}
+
+// readyz serves the readiness of the instances
+// ready if all instances are online and answer the Server List Ping
+func readyz(wrappers []*wrapper.Wrapper) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hs := healths(wrappers)
+
+		code := http.StatusOK
+		if atomic.LoadInt32(&healthy) != 1 {
+			code = http.StatusServiceUnavailable
+		}
+		for _, h := range hs {
+			if !h.Ready {
+				code = http.StatusServiceUnavailable
+			}
 		}
-		w.WriteHeader(http.StatusServiceUnavailable)
+		writeJSON(w, code, hs)
 	})
 }
 
+// serveQuery serves the full stat queried by the Query protocol
+func serveQuery(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, wr.Query())
+}
+
+// serveStatus serves the status queried by Server List Ping
+func serveStatus(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, wr.Status())
+}
+
 func serveHome(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
 	tmpl, err := template.ParseFiles("template/home.html")
 	if err != nil {
@@ -43,8 +119,11 @@ func serveHome(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
 	}
 
 	data := IndexTemplate{
-		State:  wr.CurrentState().String(),
-		Prefix: viper.GetString("web.prefix"),
+		State:    wr.CurrentState().String(),
+		Prefix:   webPrefix,
+		Base:     instanceBase(wr.Name()),
+		Instance: wr.Name(),
+		Pending:  wr.Pending(),
 	}
 
 	switch wrapper.ServerStateFor(data.State) {
@@ -56,9 +135,49 @@ func serveHome(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
 		data.Offline = true
 	}
 
-	data.Log = latestLog()
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+	}
+}
+
+// servePage serves a page of the instance
+func servePage(file string, wr *wrapper.Wrapper, w http.ResponseWriter) {
+	tmpl, err := template.ParseFiles(file)
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	data := PageTemplate{
+		Prefix:   webPrefix,
+		Base:     instanceBase(wr.Name()),
+		Instance: wr.Name(),
+	}
 
 	if err := tmpl.Execute(w, data); err != nil {
 		http.Error(w, "Something went wrong", http.StatusInternalServerError)
 	}
 }
+
+// serveOverview serves the overview of all instances
+func serveOverview(wrappers []*wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFiles("template/overview.html")
+	if err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+		return
+	}
+
+	data := OverviewTemplate{
+		Prefix:  webPrefix,
+		Servers: serverInfos(wrappers),
+	}
+
+	if err := tmpl.Execute(w, data); err != nil {
+		http.Error(w, "Something went wrong", http.StatusInternalServerError)
+	}
+}
+
+// serveServers serves the instances with their states
+func serveServers(wrappers []*wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, serverInfos(wrappers))
+}
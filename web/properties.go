@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/wrapper"
+)
+
+// propertiesRequest body to update the server.properties
+type propertiesRequest struct {
+	Properties map[string]string `json:"properties"`
+	Restart    bool              `json:"restart"`
+}
+
+// registerProperties registers the API and page to edit the server.properties
+func registerProperties(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/settings", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { serveSettings(wr, w, r) })).Methods("GET")
+	router.HandleFunc(prefix+"/api/properties", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { serveProperties(wr, w, r) })).Methods("GET")
+	router.HandleFunc(prefix+"/api/properties", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { updateProperties(wr, w, r) })).Methods("PUT")
+}
+
+// serveProperties serves the server.properties in file order
+func serveProperties(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	props, err := wr.ServerProperties()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, props)
+}
+
+// updateProperties validates and writes the changed properties
+func updateProperties(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	var req propertiesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	update, err := wr.UpdateProperties(req.Properties, req.Restart)
+	if errors.Is(err, wrapper.ErrInvalidProperties) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, update)
+}
+
+// serveSettings serves the page to edit the server.properties
+func serveSettings(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	servePage("template/settings.html", wr, w)
+}
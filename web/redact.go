@@ -0,0 +1,93 @@
+package web
+
+import (
+	"regexp"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/momper14/msw/wrapper/sessions"
+)
+
+// the addresses of the players are only for admins,
+// the responses and messages for other roles pass through redact
+
+// addrRegexp matches the addresses the Minecraft Server logs
+// like /127.0.0.1:54321, /[0:0:0:0:0:0:0:1]:54321, /0:0:0:0:0:0:0:1:54321 and the banned 127.0.0.1
+var addrRegexp = regexp.MustCompile(`/(?:\[[0-9a-fA-F:.%]+\]|[0-9a-fA-F]{0,4}(?::[0-9a-fA-F]{0,4}){2,7}):\d+|/?\b\d{1,3}(?:\.\d{1,3}){3}\b(?::\d+)?`)
+
+// redactedAddr replaces a hidden address
+const redactedAddr = "<hidden>"
+
+// maySeeIPs reports if the role may see the addresses of the players
+func maySeeIPs(role Role) bool {
+	return role == RoleAdmin
+}
+
+// redactLine hides the addresses in the log line
+func redactLine(line string) string {
+	return addrRegexp.ReplaceAllString(line, redactedAddr)
+}
+
+// redactLines returns redactLine for roles which may not see the addresses, otherwise nil
+func redactLines(role Role) func(string) string {
+	if maySeeIPs(role) {
+		return nil
+	}
+	return redactLine
+}
+
+// redactPlayers returns the players without their addresses unless the role may see them
+func redactPlayers(role Role, players []model.Player) []model.Player {
+	if maySeeIPs(role) {
+		return players
+	}
+
+	redacted := make([]model.Player, len(players))
+	for i, p := range players {
+		p.IP = ""
+		redacted[i] = p
+	}
+	return redacted
+}
+
+// redactSessions removes the addresses of the sessions unless the role may see them
+func redactSessions(role Role, list []sessions.Session) {
+	if maySeeIPs(role) {
+		return
+	}
+
+	for i := range list {
+		list[i].IP = ""
+	}
+}
+
+// redact returns the message without the addresses unless the role may see them
+// the message is copied if it contains any
+func redact(role Role, m *model.Message) *model.Message {
+	if maySeeIPs(role) {
+		return m
+	}
+
+	switch payload := m.Payload.(type) {
+	case string:
+		return &model.Message{Type: m.Type, Payload: redactLine(payload)}
+	case []model.Player:
+		return &model.Message{Type: m.Type, Payload: redactPlayers(role, payload)}
+	case model.PlayerJoin:
+		payload.IP = ""
+		return &model.Message{Type: m.Type, Payload: payload}
+	case model.Result:
+		return &model.Message{Type: m.Type, Payload: redactResult(role, payload)}
+	}
+	return m
+}
+
+// redactResult returns the result without the addresses unless the role may see them
+func redactResult(role Role, res model.Result) model.Result {
+	if maySeeIPs(role) {
+		return res
+	}
+
+	res.Output = redactLine(res.Output)
+	res.Error = redactLine(res.Error)
+	return res
+}
@@ -0,0 +1,51 @@
+package web
+
+import "fmt"
+
+// Role enum of user roles
+type Role int
+
+// possible roles
+const (
+	RoleViewer Role = iota + 1
+	RoleOperator
+	RoleAdmin
+)
+
+var rolemap = map[Role]string{
+	RoleViewer:   "viewer",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+func (r Role) String() string {
+	if val, ok := rolemap[r]; ok {
+		return val
+	}
+
+	return "unknown"
+}
+
+// RoleFor returns Role for the given string
+// ignores errors
+func RoleFor(s string) Role {
+	role, _ := RoleForE(s)
+	return role
+}
+
+// RoleForE returns Role for the given string
+func RoleForE(s string) (Role, error) {
+	for k, v := range rolemap {
+		if v == s {
+			return k, nil
+		}
+	}
+
+	return Role(0), fmt.Errorf("no known role for %s", s)
+}
+
+// Validate validates that the value is a valide enum value
+func (r Role) Validate() (ok bool) {
+	_, ok = rolemap[r]
+	return
+}
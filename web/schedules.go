@@ -0,0 +1,47 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/wrapper"
+)
+
+// registerSchedules registers the API to manage the scheduled jobs
+func registerSchedules(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/api/schedules", func(w http.ResponseWriter, r *http.Request) { serveSchedules(wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/schedules", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { addSchedule(wr, w, r) })).Methods("POST")
+	router.HandleFunc(prefix+"/api/schedules/{name}", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { removeSchedule(wr, w, r) })).Methods("DELETE")
+}
+
+// serveSchedules serves the scheduled jobs
+func serveSchedules(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, wr.Schedules())
+}
+
+// addSchedule adds or replaces the job of the request body
+func addSchedule(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	var job wrapper.Job
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := wr.AddSchedule(job); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, job)
+}
+
+// removeSchedule removes the job
+func removeSchedule(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	if err := wr.RemoveSchedule(mux.Vars(r)["name"]); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
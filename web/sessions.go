@@ -0,0 +1,71 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/wrapper"
+	"github.com/momper14/msw/wrapper/sessions"
+	"github.com/shaj13/go-guardian/v2/auth"
+)
+
+// playerSessions the sessions of a player with their aggregates
+type playerSessions struct {
+	Player   sessions.PlayerStats `json:"player"`
+	Sessions []sessions.Session   `json:"sessions"`
+}
+
+// sessionStats the aggregated sessions of all players
+type sessionStats struct {
+	Players []sessions.PlayerStats `json:"players"`
+	Peaks   []sessions.DailyPeak   `json:"peaks"`
+}
+
+// registerSessions registers the API and page of the player sessions
+func registerSessions(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/players", func(w http.ResponseWriter, r *http.Request) { servePage("template/players.html", wr, w) }).Methods("GET")
+
+	router.HandleFunc(prefix+"/api/players/{uuid}/sessions", func(w http.ResponseWriter, r *http.Request) { servePlayerSessions(wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/sessions", func(w http.ResponseWriter, r *http.Request) { serveSessionStats(wr, w, r) }).Methods("GET")
+	router.HandleFunc(prefix+"/api/sessions/backfill", requireRole(RoleAdmin, func(w http.ResponseWriter, r *http.Request) { serveBackfill(wr, w, r) })).Methods("POST")
+}
+
+// servePlayerSessions serves the sessions of the player by uuid or name
+// the ip of the sessions is left out unless the user is admin
+func servePlayerSessions(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	stats, list, err := wr.PlayerSessions(mux.Vars(r)["uuid"])
+	if errors.Is(err, sessions.ErrUnknownPlayer) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	redactSessions(roleOf(auth.User(r)), list)
+	writeJSON(w, http.StatusOK, playerSessions{Player: stats, Sessions: list})
+}
+
+// serveSessionStats serves the aggregated sessions of all players
+func serveSessionStats(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	players, peaks, err := wr.SessionStats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessionStats{Players: players, Peaks: peaks})
+}
+
+// serveBackfill records the sessions of the rotated logs
+func serveBackfill(wr *wrapper.Wrapper, w http.ResponseWriter, r *http.Request) {
+	added, err := wr.Backfill()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"added": added})
+}
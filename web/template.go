@@ -3,9 +3,24 @@ package web
 // IndexTemplate struct to fill the index template
 type IndexTemplate struct {
 	State    string
-	Log      []string
 	Starting bool
 	Online   bool
 	Offline  bool
 	Prefix   string
+	Base     string
+	Instance string
+	Pending  []string
+}
+
+// PageTemplate struct to fill the templates of the other pages of an instance
+type PageTemplate struct {
+	Prefix   string
+	Base     string
+	Instance string
+}
+
+// OverviewTemplate struct to fill the overview template
+type OverviewTemplate struct {
+	Prefix  string
+	Servers []ServerInfo
 }
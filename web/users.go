@@ -0,0 +1,171 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/shaj13/go-guardian/v2/auth"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// user a configured user with bcrypt hashed password
+type user struct {
+	Name     string
+	Password string
+	Role     string
+}
+
+// userStore the configured users by name
+type userStore map[string]user
+
+// credentials the users allowed to login and the permissions of their roles
+type credentials struct {
+	users       userStore
+	permissions map[Role]map[model.CommandTarget][]string
+	// fallback login if no users are configured
+	user     string
+	password string
+}
+
+var (
+	credentialsMu sync.RWMutex
+	creds         credentials
+)
+
+// loadCredentials loads the users, permissions and fallback login
+func loadCredentials() (credentials, error) {
+	users, err := loadUsers()
+	if err != nil {
+		return credentials{}, err
+	}
+
+	return credentials{
+		users:       users,
+		permissions: loadPermissions(),
+		user:        viper.GetString("web.user"),
+		password:    viper.GetString("web.password"),
+	}, nil
+}
+
+// currentCredentials returns the credentials in use
+func currentCredentials() credentials {
+	credentialsMu.RLock()
+	defer credentialsMu.RUnlock()
+
+	return creds
+}
+
+// reloadCredentials loads the credentials of the reread config
+// cached logins are dropped when applied, so removed users are logged out
+func reloadCredentials() (func(), error) {
+	c, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		credentialsMu.Lock()
+		creds = c
+		credentialsMu.Unlock()
+
+		cache.Clear()
+	}, nil
+}
+
+// loadUsers loads the users from web.users and web.usersfile
+// falls back to web.user and web.password as admin if no users are configured
+func loadUsers() (userStore, error) {
+	var list []user
+	if err := viper.UnmarshalKey("web.users", &list); err != nil {
+		return nil, err
+	}
+
+	if file := viper.GetString("web.usersfile"); file != "" {
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, err
+		}
+
+		var fromFile []user
+		if err := v.UnmarshalKey("users", &fromFile); err != nil {
+			return nil, err
+		}
+		list = append(list, fromFile...)
+	}
+
+	store := make(userStore)
+	for _, u := range list {
+		if u.Name == "" {
+			return nil, fmt.Errorf("user without name")
+		}
+		if !RoleFor(u.Role).Validate() {
+			return nil, fmt.Errorf("invalid role %s of user %s", u.Role, u.Name)
+		}
+		if _, err := bcrypt.Cost([]byte(u.Password)); err != nil {
+			return nil, fmt.Errorf("password of user %s is no bcrypt hash: %v", u.Name, err)
+		}
+		store[u.Name] = u
+	}
+
+	return store, nil
+}
+
+// loadPermissions loads the allowed command prefixes of the roles
+func loadPermissions() map[Role]map[model.CommandTarget][]string {
+	perms := make(map[Role]map[model.CommandTarget][]string)
+	for _, role := range []Role{RoleViewer, RoleOperator} {
+		perms[role] = map[model.CommandTarget][]string{
+			model.TargetWrapper: viper.GetStringSlice(fmt.Sprintf("web.permissions.%s.wrapper", role)),
+			model.TargetServer:  viper.GetStringSlice(fmt.Sprintf("web.permissions.%s.server", role)),
+		}
+	}
+	return perms
+}
+
+// validateUser validates the credentials against the user store
+func validateUser(ctx context.Context, r *http.Request, userName, password string) (auth.Info, error) {
+	c := currentCredentials()
+	if len(c.users) == 0 {
+		if userName == c.user && password == c.password {
+			return auth.NewDefaultUser(userName, userName, []string{RoleAdmin.String()}, nil), nil
+		}
+		return nil, fmt.Errorf("Invalid credentials")
+	}
+
+	u, ok := c.users[userName]
+	if !ok || bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return nil, fmt.Errorf("Invalid credentials")
+	}
+
+	return auth.NewDefaultUser(u.Name, u.Name, []string{u.Role}, nil), nil
+}
+
+// roleOf returns the role of the authenticated user
+func roleOf(info auth.Info) Role {
+	if info == nil || len(info.GetGroups()) == 0 {
+		return RoleViewer
+	}
+	return RoleFor(info.GetGroups()[0])
+}
+
+// authorize checks if the role is allowed to execute the command
+func authorize(role Role, command *model.Command) error {
+	if role == RoleAdmin {
+		return nil
+	}
+
+	payload := strings.TrimPrefix(strings.TrimSpace(command.Payload), "/")
+	for _, prefix := range currentCredentials().permissions[role][command.Target] {
+		if payload == prefix || strings.HasPrefix(payload, prefix+" ") {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("permission denied for %s command \"%s\"", command.Target, command.Payload)
+}
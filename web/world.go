@@ -0,0 +1,42 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/gorilla/mux"
+	"github.com/momper14/msw/wrapper"
+	"github.com/momper14/msw/wrapper/world"
+)
+
+// registerWorld registers the read-only API of the player files of the world
+// players are given by uuid or name
+func registerWorld(router *mux.Router, prefix string, wr *wrapper.Wrapper) {
+	router.HandleFunc(prefix+"/api/players/{uuid}/data", func(w http.ResponseWriter, r *http.Request) {
+		p, err := wr.PlayerData(mux.Vars(r)["uuid"])
+		serveWorld(w, p, err)
+	}).Methods("GET")
+	router.HandleFunc(prefix+"/api/players/{uuid}/stats", func(w http.ResponseWriter, r *http.Request) {
+		s, err := wr.PlayerStatistics(mux.Vars(r)["uuid"])
+		serveWorld(w, s, err)
+	}).Methods("GET")
+	router.HandleFunc(prefix+"/api/players/{uuid}/advancements", func(w http.ResponseWriter, r *http.Request) {
+		a, err := wr.PlayerAdvancements(mux.Vars(r)["uuid"])
+		serveWorld(w, a, err)
+	}).Methods("GET")
+}
+
+// serveWorld serves the content of a player file or the error
+func serveWorld(w http.ResponseWriter, v interface{}, err error) {
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, v)
+	case errors.Is(err, world.ErrInvalidUUID):
+		writeError(w, http.StatusBadRequest, err)
+	case errors.Is(err, os.ErrNotExist), errors.Is(err, wrapper.ErrUnknownPlayer):
+		writeError(w, http.StatusNotFound, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,279 @@
+package wrapper
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// archive formats
+const (
+	formatTarGz = "tar.gz"
+	formatZip   = "zip"
+)
+
+// archiveWriter writes files into an archive
+type archiveWriter interface {
+	add(name string, info os.FileInfo, path string) error
+	Close() error
+}
+
+// tarGzWriter writes tar.gz archives
+type tarGzWriter struct {
+	gz  *gzip.Writer
+	tar *tar.Writer
+}
+
+func (t *tarGzWriter) add(name string, info os.FileInfo, path string) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	if err := t.tar.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return nil
+	}
+	return copyFileTo(t.tar, path)
+}
+
+func (t *tarGzWriter) Close() error {
+	if err := t.tar.Close(); err != nil {
+		return err
+	}
+	return t.gz.Close()
+}
+
+// zipWriter writes zip archives
+type zipWriter struct {
+	zip *zip.Writer
+}
+
+func (z *zipWriter) add(name string, info os.FileInfo, path string) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if info.IsDir() {
+		header.Name += "/"
+	} else {
+		header.Method = zip.Deflate
+	}
+
+	w, err := z.zip.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return nil
+	}
+	return copyFileTo(w, path)
+}
+
+func (z *zipWriter) Close() error {
+	return z.zip.Close()
+}
+
+// copyFileTo copies the file at path to w
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// createArchive archives the dirs inside of root into the file dst
+// missing dirs are skipped
+func createArchive(dst, format, root string, dirs []string) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var aw archiveWriter
+	switch format {
+	case formatTarGz:
+		gz := gzip.NewWriter(f)
+		aw = &tarGzWriter{gz: gz, tar: tar.NewWriter(gz)}
+	case formatZip:
+		aw = &zipWriter{zip: zip.NewWriter(f)}
+	default:
+		return fmt.Errorf("unknown archive format %s", format)
+	}
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(filepath.Join(root, dir)); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.Walk(filepath.Join(root, dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				return nil
+			}
+
+			name, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			return aw.add(filepath.ToSlash(name), info, path)
+		})
+		if err != nil {
+			aw.Close()
+			return err
+		}
+	}
+
+	if err := aw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// archiveFormat returns the format of the archive by its name
+func archiveFormat(name string) (string, error) {
+	switch {
+	case strings.HasSuffix(name, "."+formatTarGz):
+		return formatTarGz, nil
+	case strings.HasSuffix(name, "."+formatZip):
+		return formatZip, nil
+	}
+	return "", fmt.Errorf("unknown archive format of %s", name)
+}
+
+// safeJoin joins name to dst and ensures the result stays inside of dst
+func safeJoin(dst, name string) (string, error) {
+	path := filepath.Join(dst, filepath.FromSlash(name))
+	if path != filepath.Clean(dst) && !strings.HasPrefix(path, filepath.Clean(dst)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("illegal path %s in archive", name)
+	}
+	return path, nil
+}
+
+// writeFile writes the content of r to path
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// extractArchive extracts the archive src into the dir dst
+func extractArchive(src, dst string) error {
+	format, err := archiveFormat(src)
+	if err != nil {
+		return err
+	}
+
+	if format == formatZip {
+		return extractZip(src, dst)
+	}
+	return extractTarGz(src, dst)
+}
+
+// extractTarGz extracts a tar.gz archive
+func extractTarGz(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path, err := safeJoin(dst, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0755)
+		case tar.TypeReg:
+			err = writeFile(path, tr, os.FileMode(header.Mode).Perm())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip extracts a zip archive
+func extractZip(src, dst string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, file := range zr.File {
+		path, err := safeJoin(dst, file.Name)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFile(path, r, file.Mode().Perm())
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
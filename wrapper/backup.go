@@ -0,0 +1,345 @@
+package wrapper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/momper14/msw/cron"
+	"github.com/sirupsen/logrus"
+)
+
+// backupTimeFormat format of the timestamp in backup names
+const backupTimeFormat = "2006-01-02_15-04-05"
+
+// backupConfig config of the world backups
+type backupConfig struct {
+	Schedule string
+	Dir      string
+	Format   string
+	Worlds   []string
+	Timeout  time.Duration
+	Keep     struct {
+		Hourly int
+		Daily  int
+		Weekly int
+	}
+}
+
+// Backup a world backup
+type Backup struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// Backups lists all backups, newest first
+func (w *Wrapper) Backups() ([]Backup, error) {
+	files, err := ioutil.ReadDir(w.conf().Backup.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		format, err := archiveFormat(f.Name())
+		if err != nil {
+			continue
+		}
+
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(f.Name(), "."+format), time.Local)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Name: f.Name(), Time: t, Size: f.Size()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// Backup creates a backup of the worlds
+// if the server is online, saving is paused while archiving
+func (w *Wrapper) Backup() (string, error) {
+	w.backupMu.Lock()
+	defer w.backupMu.Unlock()
+
+	switch cs := w.CurrentState(); cs {
+	case ServerOnline:
+		if err := w.saveOff(); err != nil {
+			return "", err
+		}
+		defer func() {
+			if err := w.console.WriteCmd("save-on"); err != nil {
+				logrus.Error(err)
+			}
+		}()
+	case ServerStarting, ServerStopping:
+		return "", fmt.Errorf("can't backup while server is %s", cs)
+	}
+
+	if err := os.MkdirAll(w.conf().Backup.Dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s.%s", time.Now().Format(backupTimeFormat), w.conf().Backup.Format)
+	dst := filepath.Join(w.conf().Backup.Dir, name)
+	if err := createArchive(dst, w.conf().Backup.Format, w.conf().Workingdir, w.conf().Backup.Worlds); err != nil {
+		os.Remove(dst)
+		return "", err
+	}
+
+	if err := w.pruneBackups(); err != nil {
+		logrus.Warnf("failed to prune backups: %v", err)
+	}
+
+	return name, nil
+}
+
+// saveOff disables saving and flushes the worlds to disk
+func (w *Wrapper) saveOff() error {
+	watcher := w.logWatchers.watch(savedRegexp)
+	defer w.logWatchers.unwatch(watcher)
+
+	if err := w.console.WriteCmd("save-off"); err != nil {
+		return err
+	}
+
+	if err := w.console.WriteCmd("save-all flush"); err != nil {
+		return err
+	}
+
+	select {
+	case <-watcher.match:
+		return nil
+	case <-time.After(w.conf().Backup.Timeout):
+		if err := w.console.WriteCmd("save-on"); err != nil {
+			logrus.Error(err)
+		}
+		return fmt.Errorf("timeout while waiting for the worlds to be saved")
+	}
+}
+
+// Restore restores the worlds of the given backup
+// a running server gets stopped and started again afterwards, also if the restore failed
+func (w *Wrapper) Restore(name string) error {
+	w.backupMu.Lock()
+	defer w.backupMu.Unlock()
+
+	src := filepath.Join(w.conf().Backup.Dir, filepath.Base(name))
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	cs := w.CurrentState()
+	switch cs {
+	case ServerStarting, ServerStopping:
+		return fmt.Errorf("can't restore while server is %s", cs)
+	case ServerOnline:
+		if err := w.Stop(); err != nil {
+			return err
+		}
+		if err := w.WaitUntilOffline(w.conf().Backup.Timeout); err != nil {
+			return err
+		}
+	}
+
+	err := w.replaceFromBackup(src)
+
+	// a failed restore keeps the current worlds, so the server is started again either way
+	if cs == ServerOnline {
+		if startErr := w.Start(); startErr != nil {
+			if err != nil {
+				return fmt.Errorf("%w, starting the server again failed: %v", err, startErr)
+			}
+			return startErr
+		}
+	}
+	return err
+}
+
+// replaceFromBackup extracts the backup and replaces the worlds with it
+func (w *Wrapper) replaceFromBackup(src string) error {
+	tmp, err := ioutil.TempDir(w.conf().Workingdir, ".restore-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extractArchive(src, tmp); err != nil {
+		return err
+	}
+
+	dirs, err := ioutil.ReadDir(tmp)
+	if err != nil {
+		return err
+	}
+
+	return w.replaceWorlds(tmp, dirs)
+}
+
+// restoreOldSuffix suffix of the current worlds while they are replaced by a restore
+const restoreOldSuffix = ".restore-old"
+
+// replaceWorlds replaces the worlds with the extracted dirs
+// the current worlds are kept until all are replaced and moved back on error
+func (w *Wrapper) replaceWorlds(tmp string, dirs []os.FileInfo) error {
+	type replaced struct {
+		world string
+		old   bool
+	}
+
+	var done []replaced
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			r := done[i]
+			if err := os.RemoveAll(r.world); err != nil {
+				logrus.Errorf("failed to roll back restore of %s: %v", r.world, err)
+				continue
+			}
+			if r.old {
+				if err := os.Rename(r.world+restoreOldSuffix, r.world); err != nil {
+					logrus.Errorf("failed to roll back restore of %s: %v", r.world, err)
+				}
+			}
+		}
+	}
+
+	for _, dir := range dirs {
+		world := filepath.Join(w.conf().Workingdir, dir.Name())
+		if err := os.RemoveAll(world + restoreOldSuffix); err != nil {
+			rollback()
+			return err
+		}
+
+		r := replaced{world: world}
+		if _, err := os.Stat(world); err == nil {
+			if err := os.Rename(world, world+restoreOldSuffix); err != nil {
+				rollback()
+				return err
+			}
+			r.old = true
+		}
+
+		if err := os.Rename(filepath.Join(tmp, dir.Name()), world); err != nil {
+			if r.old {
+				if err := os.Rename(world+restoreOldSuffix, world); err != nil {
+					logrus.Errorf("failed to roll back restore of %s: %v", world, err)
+				}
+			}
+			rollback()
+			return err
+		}
+		done = append(done, r)
+	}
+
+	for _, r := range done {
+		if r.old {
+			if err := os.RemoveAll(r.world + restoreOldSuffix); err != nil {
+				logrus.Warn(err)
+			}
+		}
+	}
+	return nil
+}
+
+// pruneBackups deletes all backups not covered by the retention policy
+// if no retention is configured, all backups are kept
+func (w *Wrapper) pruneBackups() error {
+	keep := w.conf().Backup.Keep
+	if keep.Hourly <= 0 && keep.Daily <= 0 && keep.Weekly <= 0 {
+		return nil
+	}
+
+	backups, err := w.Backups()
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool)
+	retain := func(n int, bucket func(time.Time) string) {
+		seen := make(map[string]bool)
+		for _, b := range backups {
+			if len(seen) >= n {
+				return
+			}
+			if key := bucket(b.Time); !seen[key] {
+				seen[key] = true
+				kept[b.Name] = true
+			}
+		}
+	}
+
+	retain(keep.Hourly, func(t time.Time) string { return t.Format("2006-01-02 15") })
+	retain(keep.Daily, func(t time.Time) string { return t.Format("2006-01-02") })
+	retain(keep.Weekly, func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+
+	for _, b := range backups {
+		if kept[b.Name] {
+			continue
+		}
+
+		logrus.Infof("deleting backup %s", b.Name)
+		if err := os.Remove(filepath.Join(w.conf().Backup.Dir, b.Name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduleBackups runs backups according to the backup schedule until stopped
+func (w *Wrapper) scheduleBackups(stop <-chan struct{}) {
+	spec := w.conf().Backup.Schedule
+	if spec == "" {
+		return
+	}
+
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		logrus.Errorf("invalid backup schedule: %v", err)
+		return
+	}
+
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-time.After(time.Until(next)):
+		case <-stop:
+			return
+		}
+
+		w.runBackup()
+	}
+}
+
+// runBackup runs a backup and publishes the result
+func (w *Wrapper) runBackup() {
+	w.publishLog("creating backup...")
+	name, err := w.Backup()
+	if err != nil {
+		logrus.Error(err)
+		w.publishLog(fmt.Sprintf("backup failed: %v", err))
+		return
+	}
+	w.publishLog(fmt.Sprintf("created backup %s", name))
+}
@@ -0,0 +1,266 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/momper14/msw/audit"
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrTimeout is returned if a command was queued but didn't finish in time
+	ErrTimeout = errors.New("timeout while waiting for the result")
+	// ErrBusy is returned if the command processing didn't take a command in time
+	ErrBusy = errors.New("command processing is busy, try again later")
+)
+
+// wrapperCommand a command the wrapper understands
+type wrapperCommand struct {
+	// async commands run in their own goroutine
+	// so they don't block the command processing
+	async bool
+	run   func(w *Wrapper, args []string) (string, error)
+}
+
+// wrapperCommands maps the names of the wrapper commands to their implementation
+var wrapperCommands = map[string]wrapperCommand{
+	"start":   {run: (*Wrapper).startCommand},
+	"restart": {run: (*Wrapper).restartCommand},
+	"stop":    {run: (*Wrapper).stopCommand},
+	"backup":  {run: (*Wrapper).backupCommand, async: true},
+	"backups": {run: (*Wrapper).backupsCommand},
+	"restore": {run: (*Wrapper).restoreCommand, async: true},
+	"cancel":  {run: (*Wrapper).cancelCommand},
+}
+
+// processCommands processes commands from the commands channel
+func (w *Wrapper) processCommands() {
+	for command := range w.commands {
+		command.Instance = w.Name()
+		target := command.Target
+		payload := command.Payload
+
+		logrus.Infof("recieved command \"%s\" from %s\n", payload, command.Issuer())
+		w.publishLog(payload)
+
+		switch target {
+		case model.TargetServer:
+			w.recordHistory(command.User, payload)
+			output, err := w.sendServerCommand(payload)
+			w.result(command, output, err)
+
+		case model.TargetWrapper:
+			w.runWrapperCommand(command)
+
+		default:
+			logrus.Warnf("invalid target %s", target)
+			w.result(command, "", fmt.Errorf("invalid target %s", target))
+		}
+	}
+}
+
+// runWrapperCommand runs a wrapper command and sends its result
+func (w *Wrapper) runWrapperCommand(command *model.Command) {
+	args := strings.Fields(command.Payload)
+	if len(args) == 0 {
+		w.result(command, "", fmt.Errorf("empty wrapper command"))
+		return
+	}
+
+	wc, ok := wrapperCommands[args[0]]
+	if !ok {
+		logrus.Warnf("unknown wrapper command: %s", command.Payload)
+		w.result(command, "", fmt.Errorf("unknown wrapper command: %s", args[0]))
+		return
+	}
+
+	run := func() {
+		output, err := wc.run(w, args[1:])
+		w.result(command, output, err)
+	}
+
+	if wc.async {
+		go run()
+		return
+	}
+	run()
+}
+
+// result sends the result of the command to its issuer
+// and records it in the audit log
+func (w *Wrapper) result(command *model.Command, output string, err error) {
+	if err != nil {
+		logrus.Error(err)
+	}
+
+	audit.RecordCommand(command, output, err)
+
+	if command.ID == "" && output == "" && err == nil {
+		return
+	}
+
+	w.reply(command, command.Result(output, err))
+}
+
+// reply sends a message to the issuer of the command
+// falls back to publishing if the issuer is unknown
+func (w *Wrapper) reply(command *model.Command, msg *model.Message) {
+	if command.Reply == nil {
+		w.publish(msg)
+		return
+	}
+
+	command.Reply(msg)
+}
+
+// startCommand starts the server
+func (w *Wrapper) startCommand(args []string) (string, error) {
+	if w.CurrentState() == ServerOnline {
+		return "server already running!", nil
+	}
+	return "", w.Start()
+}
+
+// parseDelay parses the optional delay argument
+func parseDelay(args []string) (time.Duration, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		delay, err := time.ParseDuration(args[0])
+		if err != nil || delay < 0 {
+			return 0, fmt.Errorf("invalid delay %s", args[0])
+		}
+		return delay, nil
+	}
+	return 0, fmt.Errorf("too many arguments")
+}
+
+// restartCommand restarts the server, optionally after a delay
+func (w *Wrapper) restartCommand(args []string) (string, error) {
+	delay, err := parseDelay(args)
+	if err != nil {
+		return "", fmt.Errorf("usage: restart [delay]: %v", err)
+	}
+
+	if delay > 0 && w.CurrentState() == ServerOnline {
+		return fmt.Sprintf("restart scheduled in %s", humanDuration(delay)), w.RestartIn(delay)
+	}
+	return "", w.Restart()
+}
+
+// stopCommand stops the server, optionally after a delay, or cancels a pending restart
+func (w *Wrapper) stopCommand(args []string) (string, error) {
+	delay, err := parseDelay(args)
+	if err != nil {
+		return "", fmt.Errorf("usage: stop [delay]: %v", err)
+	}
+
+	cs := w.CurrentState()
+	if delay > 0 && cs == ServerOnline {
+		return fmt.Sprintf("stop scheduled in %s", humanDuration(delay)), w.StopIn(delay)
+	}
+	if cs == ServerStarting || cs == ServerOnline {
+		return "", w.Stop()
+	}
+
+	if w.restarter.cancel() {
+		w.publishRestart("pending restart cancelled")
+		return "", nil
+	}
+
+	return "server not running!", nil
+}
+
+// cancelCommand cancels a pending shutdown or restart
+func (w *Wrapper) cancelCommand(args []string) (string, error) {
+	if w.CancelShutdown() {
+		return "", nil
+	}
+
+	if w.restarter.cancel() {
+		w.publishRestart("pending restart cancelled")
+		return "", nil
+	}
+
+	return "nothing to cancel", nil
+}
+
+// backupCommand creates a backup
+func (w *Wrapper) backupCommand(args []string) (string, error) {
+	w.publishLog("creating backup...")
+	name, err := w.Backup()
+	if err != nil {
+		return "", fmt.Errorf("backup failed: %v", err)
+	}
+	return fmt.Sprintf("created backup %s", name), nil
+}
+
+// backupsCommand lists the backups
+func (w *Wrapper) backupsCommand(args []string) (string, error) {
+	backups, err := w.Backups()
+	if err != nil {
+		return "", err
+	}
+
+	if len(backups) == 0 {
+		return "no backups found", nil
+	}
+
+	lines := make([]string, 0, len(backups))
+	for _, b := range backups {
+		lines = append(lines, fmt.Sprintf("%s (%d MiB)", b.Name, b.Size>>20))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// restoreCommand restores a backup
+func (w *Wrapper) restoreCommand(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: restore <backup>")
+	}
+
+	w.publishLog(fmt.Sprintf("restoring backup %s...", args[0]))
+	if err := w.Restore(args[0]); err != nil {
+		return "", fmt.Errorf("restore failed: %v", err)
+	}
+	return fmt.Sprintf("restored backup %s", args[0]), nil
+}
+
+// Execute sends the command to the command processing and waits for its result
+// returns ErrBusy if the command couldn't be queued
+// and ErrTimeout if no result arrives within the timeout
+func (w *Wrapper) Execute(command *model.Command, timeout time.Duration) (*model.Result, error) {
+	if command.ID == "" {
+		command.ID = "execute"
+	}
+
+	results := make(chan model.Result, 1)
+	command.Reply = func(m *model.Message) {
+		if res, ok := m.Payload.(model.Result); ok {
+			select {
+			case results <- res:
+			default:
+			}
+		}
+	}
+
+	// the command processing may be busy, the timeout covers the send too
+	deadline := time.After(timeout)
+	select {
+	case w.commands <- command:
+	case <-deadline:
+		return nil, ErrBusy
+	}
+
+	select {
+	case res := <-results:
+		return &res, nil
+	case <-deadline:
+		return nil, ErrTimeout
+	}
+}
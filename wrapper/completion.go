@@ -0,0 +1,164 @@
+package wrapper
+
+import (
+	"sort"
+	"strings"
+)
+
+// playerArg placeholder of an argument completed with the online players
+const playerArg = "<player>"
+
+// playerSelectors target selectors completed with the players
+var playerSelectors = []string{"@a", "@p", "@r", "@s"}
+
+// vanillaCommands the vanilla commands with the arguments worth completing
+// each word is an argument, alternatives are separated by |
+var vanillaCommands = []string{
+	"advancement grant|revoke " + playerArg + " everything|only|from|through|until",
+	"ban " + playerArg,
+	"ban-ip " + playerArg,
+	"banlist ips|players",
+	"clear " + playerArg,
+	"defaultgamemode survival|creative|adventure|spectator",
+	"deop " + playerArg,
+	"difficulty peaceful|easy|normal|hard",
+	"effect give|clear " + playerArg,
+	"enchant " + playerArg,
+	"experience add|set|query " + playerArg,
+	"gamemode survival|creative|adventure|spectator " + playerArg,
+	"gamerule",
+	"give " + playerArg,
+	"help",
+	"kick " + playerArg,
+	"kill " + playerArg,
+	"list uuids",
+	"locate",
+	"me",
+	"msg " + playerArg,
+	"op " + playerArg,
+	"pardon",
+	"pardon-ip",
+	"playsound",
+	"reload",
+	"save-all flush",
+	"save-off",
+	"save-on",
+	"say",
+	"seed",
+	"setidletimeout",
+	"setworldspawn",
+	"spawnpoint " + playerArg,
+	"spectate",
+	"stop",
+	"stopsound " + playerArg,
+	"summon",
+	"teleport " + playerArg + " " + playerArg,
+	"tell " + playerArg,
+	"tellraw " + playerArg,
+	"time set|add|query",
+	"time set day|night|noon|midnight",
+	"time query daytime|gametime|day",
+	"title " + playerArg + " clear|reset|title|subtitle|actionbar|times",
+	"tp " + playerArg + " " + playerArg,
+	"w " + playerArg,
+	"weather clear|rain|thunder",
+	"whitelist on|off|list|reload|add|remove",
+	"whitelist add|remove " + playerArg,
+	"worldborder add|center|damage|get|set|warning",
+	"xp add|set|query " + playerArg,
+}
+
+// commandNode a node of the command tree, its children are the possible next arguments
+type commandNode map[string]commandNode
+
+// commandTree the tree of the vanilla commands
+var commandTree = buildCommandTree(vanillaCommands)
+
+// buildCommandTree builds the tree of the commands
+// commands with the same beginning are merged
+func buildCommandTree(commands []string) commandNode {
+	root := make(commandNode)
+	for _, command := range commands {
+		nodes := []commandNode{root}
+		for _, arg := range strings.Fields(command) {
+			var next []commandNode
+			for _, node := range nodes {
+				for _, alt := range strings.Split(arg, "|") {
+					if node[alt] == nil {
+						node[alt] = make(commandNode)
+					}
+					next = append(next, node[alt])
+				}
+			}
+			nodes = next
+		}
+	}
+	return root
+}
+
+// child returns the child matching the argument
+func (n commandNode) child(arg string) commandNode {
+	for name, child := range n {
+		if strings.EqualFold(name, arg) {
+			return child
+		}
+	}
+	return n[playerArg]
+}
+
+// candidates returns the arguments of the node starting with the prefix
+func (n commandNode) candidates(prefix string, players []string) []string {
+	var candidates []string
+	add := func(arg string) {
+		if strings.HasPrefix(strings.ToLower(arg), strings.ToLower(prefix)) {
+			candidates = append(candidates, arg)
+		}
+	}
+
+	for name := range n {
+		if name != playerArg {
+			add(name)
+		}
+	}
+	if _, ok := n[playerArg]; ok {
+		for _, player := range append(players, playerSelectors...) {
+			add(player)
+		}
+	}
+
+	sort.Strings(candidates)
+	return candidates
+}
+
+// Complete returns the console inputs completing the last argument of the input
+// arguments are completed by the vanilla commands and the online players
+func (w *Wrapper) Complete(input string) []string {
+	slash := strings.HasPrefix(input, "/")
+	args := strings.Split(strings.TrimPrefix(input, "/"), " ")
+
+	node := commandTree
+	for _, arg := range args[:len(args)-1] {
+		if node = node.child(arg); node == nil {
+			return make([]string, 0)
+		}
+	}
+
+	players := make([]string, 0)
+	for _, p := range w.Players() {
+		players = append(players, p.Name)
+	}
+
+	head := strings.Join(args[:len(args)-1], " ")
+	if head != "" {
+		head += " "
+	}
+	if slash {
+		head = "/" + head
+	}
+
+	suggestions := make([]string, 0)
+	for _, candidate := range node.candidates(args[len(args)-1], players) {
+		suggestions = append(suggestions, head+candidate)
+	}
+	return suggestions
+}
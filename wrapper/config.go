@@ -0,0 +1,194 @@
+package wrapper
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/momper14/viperfix"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+)
+
+// defaultInstance name of the instance if mc isn't a list
+const defaultInstance = "default"
+
+// instanceNameRegexp allowed names of instances, they are part of the urls
+var instanceNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// instanceConfig config of a Minecraft Server instance
+type instanceConfig struct {
+	Name       string
+	Heapsize   int
+	Jar        string
+	Workingdir string
+	Eula       bool
+	JvmArgs    []string
+	ServerArgs []string
+	Launch     launchConfig
+	Restart    restartConfig
+	Backup     backupConfig
+	Rcon       rconConfig
+	Countdown  countdownConfig
+	Metrics    metricsConfig
+	Stats      statsConfig
+	Slp        slpConfig
+	Query      queryConfig
+	History    historyConfig
+	Sessions   sessionsConfig
+	Schedule   map[string]jobConfig
+}
+
+// inits viper
+func init() {
+	setDefaults(viper.GetViper())
+}
+
+// setDefaults sets the defaults of an instance
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("mc.heapsize", 4096)
+	v.SetDefault("mc.jar", "server.jar")
+	v.SetDefault("mc.workingdir", "server")
+	v.SetDefault("mc.eula", false)
+	v.SetDefault("mc.launch.profile", ProfileOpenJ9.String())
+	v.SetDefault("mc.launch.java", "java")
+	v.SetDefault("mc.launch.script", "")
+	v.SetDefault("mc.launch.argsfiles", []string{})
+	v.SetDefault("mc.restart.policy", RestartNever.String())
+	v.SetDefault("mc.restart.maxretries", 5)
+	v.SetDefault("mc.restart.backoff", 5*time.Second)
+	v.SetDefault("mc.restart.maxbackoff", 5*time.Minute)
+	v.SetDefault("mc.restart.crashloop.count", 5)
+	v.SetDefault("mc.restart.crashloop.window", 10*time.Minute)
+	v.SetDefault("mc.backup.schedule", "")
+	v.SetDefault("mc.backup.dir", "backups")
+	v.SetDefault("mc.backup.format", formatTarGz)
+	v.SetDefault("mc.backup.worlds", []string{"world", "world_nether", "world_the_end"})
+	v.SetDefault("mc.backup.timeout", time.Minute)
+	v.SetDefault("mc.backup.keep.hourly", 24)
+	v.SetDefault("mc.backup.keep.daily", 7)
+	v.SetDefault("mc.backup.keep.weekly", 4)
+	v.SetDefault("mc.rcon.enabled", false)
+	v.SetDefault("mc.rcon.host", "localhost")
+	v.SetDefault("mc.rcon.port", 0)
+	v.SetDefault("mc.rcon.password", "")
+	v.SetDefault("mc.rcon.timeout", 5*time.Second)
+	v.SetDefault("mc.countdown.intervals", []string{"30m", "15m", "10m", "5m", "1m", "30s", "10s", "5s", "4s", "3s", "2s", "1s"})
+	v.SetDefault("mc.countdown.title", true)
+	v.SetDefault("mc.countdown.signal", 10*time.Second)
+	v.SetDefault("mc.metrics.tps.command", "")
+	v.SetDefault("mc.metrics.tps.interval", 30*time.Second)
+	v.SetDefault("mc.stats.interval", 10*time.Second)
+	v.SetDefault("mc.stats.retention", time.Hour)
+	v.SetDefault("mc.slp.enabled", true)
+	v.SetDefault("mc.slp.host", "")
+	v.SetDefault("mc.slp.interval", 10*time.Second)
+	v.SetDefault("mc.slp.timeout", 5*time.Second)
+	v.SetDefault("mc.query.enabled", true)
+	v.SetDefault("mc.query.host", "")
+	v.SetDefault("mc.query.port", 0)
+	v.SetDefault("mc.query.interval", 30*time.Second)
+	v.SetDefault("mc.query.timeout", 5*time.Second)
+	v.SetDefault("mc.history.file", "history.json")
+	v.SetDefault("mc.history.size", 100)
+	v.SetDefault("mc.sessions.file", "sessions.db")
+}
+
+// loadConfigs loads the configs of the instances
+// mc is either a list of named instances or a single instance,
+// instances of a list default to their name as working directory
+// and to an own backup directory, history and sessions file
+func loadConfigs() ([]*instanceConfig, error) {
+	var configs []*instanceConfig
+
+	if list, ok := viper.Get("mc").([]interface{}); ok {
+		for i, item := range list {
+			raw, err := cast.ToStringMapE(item)
+			if err != nil {
+				return nil, fmt.Errorf("mc[%d]: %v", i, err)
+			}
+
+			name := cast.ToString(raw["name"])
+			v := viper.New()
+			setDefaults(v)
+			v.SetDefault("mc.workingdir", name)
+			v.SetDefault("mc.backup.dir", filepath.Join("backups", name))
+			v.SetDefault("mc.history.file", filepath.Join("history", name+".json"))
+			v.SetDefault("mc.sessions.file", filepath.Join("sessions", name+".db"))
+			if err := v.MergeConfigMap(map[string]interface{}{"mc": raw}); err != nil {
+				return nil, fmt.Errorf("mc[%d]: %v", i, err)
+			}
+
+			c := new(instanceConfig)
+			if err := viperfix.UnmarshalKeyFrom(v, "mc", c); err != nil {
+				return nil, fmt.Errorf("mc[%d]: %v", i, err)
+			}
+			configs = append(configs, c)
+		}
+	} else {
+		c := new(instanceConfig)
+		if err := viperfix.UnmarshalKey("mc", c); err != nil {
+			return nil, err
+		}
+		if c.Name == "" {
+			c.Name = defaultInstance
+		}
+		if len(c.Schedule) == 0 {
+			if err := viperfix.UnmarshalKey("schedule", &c.Schedule); err != nil {
+				return nil, err
+			}
+		}
+		configs = append(configs, c)
+	}
+
+	return configs, validateConfigs(configs)
+}
+
+// validateConfigs validates the configs of the instances
+// instances must not share their name, working or backup directory, history or sessions file
+func validateConfigs(configs []*instanceConfig) error {
+	names := make(map[string]bool)
+	dirs := make(map[string]string)
+
+	for _, c := range configs {
+		if !instanceNameRegexp.MatchString(c.Name) {
+			return fmt.Errorf("invalid instance name %q", c.Name)
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate instance %s", c.Name)
+		}
+		names[c.Name] = true
+
+		if !RestartPolicyFor(c.Restart.Policy).Validate() {
+			return fmt.Errorf("instance %s: invalid restart policy %s", c.Name, c.Restart.Policy)
+		}
+
+		if !LaunchProfileFor(c.Launch.Profile).Validate() {
+			return fmt.Errorf("instance %s: invalid launch profile %s", c.Name, c.Launch.Profile)
+		}
+		if _, err := splitAll(c.JvmArgs); err != nil {
+			return fmt.Errorf("instance %s: invalid jvmArgs: %v", c.Name, err)
+		}
+		if _, err := splitAll(c.ServerArgs); err != nil {
+			return fmt.Errorf("instance %s: invalid serverArgs: %v", c.Name, err)
+		}
+
+		if c.Backup.Format != formatTarGz && c.Backup.Format != formatZip {
+			return fmt.Errorf("instance %s: invalid backup format %s", c.Name, c.Backup.Format)
+		}
+
+		for _, dir := range []string{c.Workingdir, c.Backup.Dir, c.History.File, c.Sessions.File} {
+			abs, err := filepath.Abs(dir)
+			if err != nil {
+				return err
+			}
+			if other, ok := dirs[abs]; ok {
+				return fmt.Errorf("instances %s and %s share %s", other, c.Name, dir)
+			}
+			dirs[abs] = c.Name
+		}
+	}
+
+	return nil
+}
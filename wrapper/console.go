@@ -57,7 +57,20 @@ func (c *console) ReadErr() (string, error) {
 	return c.stderr.ReadString('\n')
 }
 
+// Pid returns the pid of the process, 0 if it isn't started
+func (c *console) Pid() int {
+	if c.cmd.Process == nil {
+		return 0
+	}
+	return c.cmd.Process.Pid
+}
+
 // Kill kills the Consoleout
 func (c *console) Kill() error {
 	return c.cmd.Process.Kill()
 }
+
+// Wait waits for the console to exit
+func (c *console) Wait() error {
+	return c.cmd.Wait()
+}
@@ -4,23 +4,37 @@ import (
 	"sync"
 	"time"
 
+	"github.com/momper14/msw/reload"
 	"github.com/sirupsen/logrus"
 )
 
-// Controller to controll the minecraft server wrapper
+// Controller to controll the minecraft server wrappers
 type Controller struct {
-	wrapper *Wrapper
+	wrappers []*Wrapper
 }
 
 // NewController creates a new MSW Controller
+// with a Wrapper for each configured instance
 func NewController() *Controller {
-	return &Controller{wrapper: NewWrapper()}
+	configs, err := loadConfigs()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	c := &Controller{}
+	for _, conf := range configs {
+		c.wrappers = append(c.wrappers, NewWrapper(conf))
+	}
+	reload.Register("mc", c.reload)
+	return c
 }
 
 // Run runs the MSW
 func (c *Controller) Run() {
-	if err := c.wrapper.Run(); err != nil {
-		logrus.Error(err)
+	for _, w := range c.wrappers {
+		if err := w.Run(); err != nil {
+			logrus.Errorf("%s: %v", w.Name(), err)
+		}
 	}
 }
 
@@ -28,29 +42,51 @@ func (c *Controller) Run() {
 func (c *Controller) Down(wg *sync.WaitGroup, timeout time.Duration) {
 	defer wg.Done()
 
-	cs := c.wrapper.CurrentState()
+	var instances sync.WaitGroup
+	instances.Add(len(c.wrappers))
+	for _, w := range c.wrappers {
+		go func(w *Wrapper) {
+			defer instances.Done()
+			c.down(w, timeout)
+		}(w)
+	}
+	instances.Wait()
+}
+
+// down stops the Minecraft Server of the Wrapper
+func (c *Controller) down(w *Wrapper, timeout time.Duration) {
+	cs := w.CurrentState()
 
 	if cs == ServerOffline {
-		logrus.Info("Minecraft Server already stopped")
+		logrus.Infof("Minecraft Server %s already stopped", w.Name())
 		return
 	}
 
-	if cs == ServerStarting || cs == ServerOnline {
-		if err := c.wrapper.Stop(); err != nil {
-			logrus.Errorf("Server Shutdown Failed:%+v", err)
+	w.CancelShutdown()
+
+	switch {
+	case cs == ServerOnline && w.conf().Countdown.Signal > 0:
+		if err := w.StopIn(w.conf().Countdown.Signal); err != nil {
+			logrus.Errorf("Server %s Shutdown Failed:%+v", w.Name(), err)
+			return
+		}
+		timeout += w.conf().Countdown.Signal
+	case cs == ServerStarting || cs == ServerOnline:
+		if err := w.Stop(); err != nil {
+			logrus.Errorf("Server %s Shutdown Failed:%+v", w.Name(), err)
 			return
 		}
 	}
 
-	if err := c.wrapper.WaitUntilOffline(timeout); err != nil {
-		logrus.Errorf("Server Shutdown Failed:%+v", err)
+	if err := w.WaitUntilOffline(timeout); err != nil {
+		logrus.Errorf("Server %s Shutdown Failed:%+v", w.Name(), err)
 		return
 	}
 
-	logrus.Info("Minecraft Server Exited Properly")
+	logrus.Infof("Minecraft Server %s Exited Properly", w.Name())
 }
 
-// Wrapper returns the Wrapper
-func (c *Controller) Wrapper() *Wrapper {
-	return c.wrapper
+// Wrappers returns the Wrappers of all instances
+func (c *Controller) Wrappers() []*Wrapper {
+	return c.wrappers
 }
@@ -0,0 +1,169 @@
+package wrapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// countdownConfig config of the shutdown countdown
+type countdownConfig struct {
+	Intervals []time.Duration
+	Title     bool
+	Signal    time.Duration
+}
+
+// countdown a pending shutdown with in-game warnings
+type countdown struct {
+	mu     sync.Mutex
+	cancel chan struct{}
+	action string
+}
+
+// humanDuration formats the duration without trailing zero units
+func humanDuration(d time.Duration) string {
+	s := d.Round(time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// StopIn stops the Minecraft Server after the delay
+// and warns the players in the meantime
+func (w *Wrapper) StopIn(delay time.Duration) error {
+	return w.shutdownIn("stop", delay, w.Stop)
+}
+
+// RestartIn restarts the Minecraft Server after the delay
+// and warns the players in the meantime
+func (w *Wrapper) RestartIn(delay time.Duration) error {
+	return w.shutdownIn("restart", delay, w.Restart)
+}
+
+// CancelShutdown cancels a pending shutdown
+// returns if a shutdown was pending
+func (w *Wrapper) CancelShutdown() bool {
+	c := w.countdown
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel == nil {
+		return false
+	}
+
+	close(c.cancel)
+	c.cancel = nil
+	w.announce(fmt.Sprintf("Server %s cancelled", c.action))
+	return true
+}
+
+// abortCountdown cancels a pending shutdown as the server went offline on its own
+// the players can't be warned anymore, so it's only logged
+func (w *Wrapper) abortCountdown() {
+	c := w.countdown
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel == nil {
+		return
+	}
+
+	close(c.cancel)
+	c.cancel = nil
+	w.publishLog(fmt.Sprintf("Server %s cancelled, the server went offline", c.action))
+}
+
+// shutdownIn runs the action after the delay
+// only one shutdown can be pending
+func (w *Wrapper) shutdownIn(action string, delay time.Duration, fn func() error) error {
+	c := w.countdown
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancel != nil {
+		return fmt.Errorf("server %s already pending, cancel it first", c.action)
+	}
+
+	cancel := make(chan struct{})
+	c.cancel = cancel
+	c.action = action
+
+	go w.runCountdown(action, delay, cancel, fn)
+	return nil
+}
+
+// runCountdown announces the remaining time at the configured intervals
+// and runs the action at the end unless it's cancelled or the server isn't online anymore
+func (w *Wrapper) runCountdown(action string, delay time.Duration, cancel chan struct{}, fn func() error) {
+	deadline := time.Now().Add(delay)
+
+	intervals := append([]time.Duration{}, w.conf().Countdown.Intervals...)
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] > intervals[j] })
+
+	w.announce(fmt.Sprintf("Server %s in %s", action, humanDuration(delay)))
+
+	for _, interval := range intervals {
+		if interval >= delay {
+			continue
+		}
+
+		select {
+		case <-time.After(time.Until(deadline.Add(-interval))):
+			w.announce(fmt.Sprintf("Server %s in %s", action, humanDuration(interval)))
+		case <-cancel:
+			return
+		}
+	}
+
+	select {
+	case <-time.After(time.Until(deadline)):
+	case <-cancel:
+		return
+	}
+
+	c := w.countdown
+	c.mu.Lock()
+	if c.cancel != cancel {
+		c.mu.Unlock()
+		return
+	}
+	c.cancel = nil
+	c.mu.Unlock()
+
+	if cs := w.CurrentState(); cs != ServerOnline {
+		w.publishLog(fmt.Sprintf("Server %s skipped, the server is %s", action, cs))
+		return
+	}
+
+	if err := fn(); err != nil {
+		logrus.Error(err)
+		w.publishLog(err.Error())
+	}
+}
+
+// announce broadcasts the message to the players and the log
+func (w *Wrapper) announce(msg string) {
+	w.publishLog(msg)
+
+	if w.CurrentState() != ServerOnline {
+		return
+	}
+
+	if _, err := w.sendServerCommand("say " + msg); err != nil {
+		logrus.Error(err)
+	}
+
+	if w.conf().Countdown.Title {
+		if _, err := w.sendServerCommand(fmt.Sprintf(`title @a actionbar {"text":%q}`, msg)); err != nil {
+			logrus.Error(err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// historyConfig config of the command history
+type historyConfig struct {
+	File string
+	Size int
+}
+
+// history the console commands of each user, persisted in a json file
+type history struct {
+	mu       sync.Mutex
+	path     string
+	commands map[string][]string
+}
+
+// load loads the history from the file if it isn't loaded yet
+// the file is only read again if its path changed, e.g. by a reload of the config
+func (h *history) load(path string) error {
+	if h.commands != nil && h.path == path {
+		return nil
+	}
+
+	commands := make(map[string][]string)
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &commands); err != nil {
+			return err
+		}
+	}
+
+	h.path = path
+	h.commands = commands
+	return nil
+}
+
+// History returns the console commands of the user, oldest first
+func (w *Wrapper) History(user string) ([]string, error) {
+	h := w.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(w.conf().History.File); err != nil {
+		return nil, err
+	}
+
+	commands := make([]string, len(h.commands[user]))
+	copy(commands, h.commands[user])
+	return commands, nil
+}
+
+// recordHistory adds the console command to the history of the user
+// repeating the last command doesn't add it again
+func (w *Wrapper) recordHistory(user, command string) {
+	c := w.conf().History
+	if user == "" || user == scheduleUser || command == "" || c.Size <= 0 {
+		return
+	}
+
+	h := w.history
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.load(c.File); err != nil {
+		logrus.Errorf("failed to load the history of %s: %v", w.Name(), err)
+		return
+	}
+
+	commands := h.commands[user]
+	if len(commands) > 0 && commands[len(commands)-1] == command {
+		return
+	}
+	commands = append(commands, command)
+	if len(commands) > c.Size {
+		commands = commands[len(commands)-c.Size:]
+	}
+	h.commands[user] = commands
+
+	if err := os.MkdirAll(filepath.Dir(c.File), 0755); err != nil {
+		logrus.Errorf("failed to save the history of %s: %v", w.Name(), err)
+		return
+	}
+	if err := writeJSONFile(c.File, h.commands); err != nil {
+		logrus.Errorf("failed to save the history of %s: %v", w.Name(), err)
+	}
+}
@@ -0,0 +1,536 @@
+package wrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/jars"
+	"github.com/sirupsen/logrus"
+)
+
+// jarDirs directories of the plugins and mods in the working directory
+var jarDirs = []string{"plugins", "mods"}
+
+const (
+	// disabledSuffix suffix of disabled jars, the server doesn't load them
+	disabledSuffix = ".disabled"
+	// stagingDir directory in the working directory with the staged changes and uploads
+	stagingDir = ".msw-staged"
+	// stagedFile file in the staging directory with the staged changes
+	stagedFile = "staged.json"
+)
+
+// staged changes of the jars
+const (
+	JarInstall = "install"
+	JarReplace = "replace"
+	JarEnable  = "enable"
+	JarDisable = "disable"
+	JarRemove  = "remove"
+)
+
+// errors of the jars
+var (
+	ErrServerStarting = errors.New("server is starting, try again later")
+	ErrInvalidJar     = errors.New("invalid jar")
+	ErrUnknownJar     = errors.New("unknown jar")
+	ErrJarConflict    = errors.New("conflicting staged change")
+)
+
+// Jar a plugin or mod jar
+type Jar struct {
+	Dir     string     `json:"dir"`
+	File    string     `json:"file"`
+	Enabled bool       `json:"enabled"`
+	Size    int64      `json:"size"`
+	ModTime time.Time  `json:"modTime"`
+	Meta    *jars.Meta `json:"meta"`
+	// Error the error reading the metadata
+	Error string `json:"error,omitempty"`
+	// Staged the change applied at the next start
+	Staged string `json:"staged,omitempty"`
+}
+
+// jarStage the changes of the jars staged until the next start, persisted in the staging directory
+type jarStage struct {
+	mu      sync.Mutex
+	path    string
+	changes map[string]string
+}
+
+// load loads the staged changes from the file if they aren't loaded yet
+func (s *jarStage) load(path string) error {
+	if s.changes != nil && s.path == path {
+		return nil
+	}
+
+	changes := make(map[string]string)
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		if err := json.Unmarshal(content, &changes); err != nil {
+			return err
+		}
+	}
+
+	s.path = path
+	s.changes = changes
+	return nil
+}
+
+// save writes the staged changes to the file, removes it without changes
+func (s *jarStage) save() error {
+	if len(s.changes) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return writeJSONFile(s.path, s.changes)
+}
+
+// stagingPath returns the path in the staging directory
+func (w *Wrapper) stagingPath(elem ...string) string {
+	return filepath.Join(append([]string{w.conf().Workingdir, stagingDir}, elem...)...)
+}
+
+// readStage calls fn with the loaded staged changes
+func (w *Wrapper) readStage(fn func(s *jarStage) error) error {
+	s := w.jarStage
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(w.stagingPath(stagedFile)); err != nil {
+		return err
+	}
+	return fn(s)
+}
+
+// updateStage changes the staged changes by fn and saves them
+// the pending changes are published afterwards
+func (w *Wrapper) updateStage(fn func(s *jarStage) error) error {
+	err := w.readStage(func(s *jarStage) error {
+		if err := fn(s); err != nil {
+			return err
+		}
+		return s.save()
+	})
+	if err != nil {
+		return err
+	}
+
+	w.publishPending(w.Pending())
+	return nil
+}
+
+// validateJar validates the directory and file name of a jar
+// prevents leaving the jar directories
+func validateJar(dir, file string) error {
+	valid := false
+	for _, d := range jarDirs {
+		if dir == d {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%w: unknown directory %s", ErrInvalidJar, dir)
+	}
+
+	name := strings.TrimSuffix(file, disabledSuffix)
+	if file != filepath.Base(file) || strings.HasPrefix(file, ".") || !strings.HasSuffix(name, ".jar") {
+		return fmt.Errorf("%w: %s", ErrInvalidJar, file)
+	}
+	return nil
+}
+
+// jarKey returns the key of the staged change of a jar
+func jarKey(dir, file string) string {
+	return dir + "/" + file
+}
+
+// splitJarKey returns the directory and file of the key of a staged change
+func splitJarKey(key string) (string, string, bool) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || validateJar(parts[0], parts[1]) != nil {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// jarTarget returns the file name the change results in, empty for a removal
+func jarTarget(file, change string) string {
+	switch change {
+	case JarEnable:
+		return strings.TrimSuffix(file, disabledSuffix)
+	case JarDisable:
+		return file + disabledSuffix
+	case JarRemove:
+		return ""
+	}
+	return file
+}
+
+// checkConflict refuses a change whose file name is used by the staged change of another jar
+// or by an installed jar, as the changes would overwrite each other
+// removals are applied first, so they don't conflict
+func (w *Wrapper) checkConflict(s *jarStage, dir, file, change string) error {
+	target := jarTarget(file, change)
+	if target == "" {
+		return nil
+	}
+
+	for key, other := range s.changes {
+		otherDir, otherFile, ok := splitJarKey(key)
+		if !ok || otherDir != dir || otherFile == file || other == JarRemove {
+			continue
+		}
+		if target == otherFile || target == jarTarget(otherFile, other) || file == jarTarget(otherFile, other) {
+			return fmt.Errorf("%w: %s of %s/%s, unstage it first", ErrJarConflict, other, dir, otherFile)
+		}
+	}
+
+	if target != file && s.changes[jarKey(dir, target)] != JarRemove {
+		if _, err := os.Stat(filepath.Join(w.conf().Workingdir, dir, target)); err == nil {
+			return fmt.Errorf("%w: %s/%s already exists", ErrJarConflict, dir, target)
+		}
+	}
+	return nil
+}
+
+// readJar returns the jar of the file
+func readJar(dir, path string, info os.FileInfo) Jar {
+	jar := Jar{
+		Dir:     dir,
+		File:    info.Name(),
+		Enabled: !strings.HasSuffix(info.Name(), disabledSuffix),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+
+	meta, err := jars.ReadMeta(path)
+	if err != nil {
+		jar.Error = err.Error()
+	}
+	jar.Meta = meta
+	return jar
+}
+
+// listJars returns the jars of the directory
+func listJars(dir, path string) ([]Jar, error) {
+	infos, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Jar
+	for _, info := range infos {
+		if info.IsDir() || validateJar(dir, info.Name()) != nil {
+			continue
+		}
+		list = append(list, readJar(dir, filepath.Join(path, info.Name()), info))
+	}
+	return list, nil
+}
+
+// Jars returns the plugins and mods with their staged changes
+// uploaded jars are listed with their staged install
+func (w *Wrapper) Jars() ([]Jar, error) {
+	var changes map[string]string
+	err := w.readStage(func(s *jarStage) error {
+		changes = make(map[string]string, len(s.changes))
+		for key, change := range s.changes {
+			changes[key] = change
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Jar, 0)
+	for _, dir := range jarDirs {
+		installed, err := listJars(dir, filepath.Join(w.conf().Workingdir, dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, jar := range installed {
+			jar.Staged = changes[jarKey(dir, jar.File)]
+			if jar.Staged == JarInstall {
+				jar.Staged = JarReplace
+			}
+			result = append(result, jar)
+		}
+
+		uploaded, err := listJars(dir, w.stagingPath(dir))
+		if err != nil {
+			return nil, err
+		}
+		for _, jar := range uploaded {
+			if changes[jarKey(dir, jar.File)] == JarInstall {
+				jar.Staged = JarInstall
+				result = append(result, jar)
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Dir != result[j].Dir {
+			return result[i].Dir < result[j].Dir
+		}
+		return strings.ToLower(result[i].File) < strings.ToLower(result[j].File)
+	})
+	return result, nil
+}
+
+// checkStaging refuses staging changes while the server is starting,
+// it is loading the jars
+func (w *Wrapper) checkStaging() error {
+	if w.machine.Is(ServerStarting.String()) {
+		return ErrServerStarting
+	}
+	return nil
+}
+
+// UploadJar stages the upload of a jar, replacing an installed jar of the same name
+func (w *Wrapper) UploadJar(dir, file string, r io.Reader) error {
+	if err := validateJar(dir, file); err != nil {
+		return err
+	}
+	if strings.HasSuffix(file, disabledSuffix) {
+		return fmt.Errorf("%w: upload the enabled jar", ErrInvalidJar)
+	}
+	if err := w.checkStaging(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(w.stagingPath(dir), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(w.stagingPath(dir), ".upload")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if _, err := jars.ReadMeta(tmp.Name()); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJar, err)
+	}
+
+	return w.updateStage(func(s *jarStage) error {
+		if err := w.checkConflict(s, dir, file, JarInstall); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp.Name(), w.stagingPath(dir, file)); err != nil {
+			return err
+		}
+		s.changes[jarKey(dir, file)] = JarInstall
+		return nil
+	})
+}
+
+// changeJar stages the change of an installed jar
+func (w *Wrapper) changeJar(dir, file, change string) error {
+	if err := validateJar(dir, file); err != nil {
+		return err
+	}
+	if err := w.checkStaging(); err != nil {
+		return err
+	}
+
+	key := jarKey(dir, file)
+	_, statErr := os.Stat(filepath.Join(w.conf().Workingdir, dir, file))
+	installed := statErr == nil
+
+	return w.updateStage(func(s *jarStage) error {
+		if change == JarRemove && s.changes[key] == JarInstall {
+			if err := os.Remove(w.stagingPath(dir, file)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			delete(s.changes, key)
+			if installed {
+				s.changes[key] = JarRemove
+			}
+			return nil
+		}
+
+		if !installed {
+			return fmt.Errorf("%w %s/%s", ErrUnknownJar, dir, file)
+		}
+		disabled := strings.HasSuffix(file, disabledSuffix)
+		if (change == JarEnable && !disabled) || (change == JarDisable && disabled) {
+			return fmt.Errorf("%w: %s is already %sd", ErrInvalidJar, file, change)
+		}
+		if err := w.checkConflict(s, dir, file, change); err != nil {
+			return err
+		}
+
+		s.changes[key] = change
+		return nil
+	})
+}
+
+// EnableJar stages enabling a disabled jar
+func (w *Wrapper) EnableJar(dir, file string) error {
+	return w.changeJar(dir, file, JarEnable)
+}
+
+// DisableJar stages disabling a jar
+func (w *Wrapper) DisableJar(dir, file string) error {
+	return w.changeJar(dir, file, JarDisable)
+}
+
+// RemoveJar stages removing a jar, a staged upload is removed directly
+func (w *Wrapper) RemoveJar(dir, file string) error {
+	return w.changeJar(dir, file, JarRemove)
+}
+
+// UnstageJar discards the staged change of the jar
+func (w *Wrapper) UnstageJar(dir, file string) error {
+	if err := validateJar(dir, file); err != nil {
+		return err
+	}
+	if err := w.checkStaging(); err != nil {
+		return err
+	}
+
+	key := jarKey(dir, file)
+	return w.updateStage(func(s *jarStage) error {
+		change, ok := s.changes[key]
+		if !ok {
+			return fmt.Errorf("%w %s/%s has no staged change", ErrUnknownJar, dir, file)
+		}
+		if change == JarInstall {
+			if err := os.Remove(w.stagingPath(dir, file)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		delete(s.changes, key)
+		return nil
+	})
+}
+
+// hasStagedJars returns if changes of the jars are staged
+func (w *Wrapper) hasStagedJars() bool {
+	staged := false
+	err := w.readStage(func(s *jarStage) error {
+		staged = len(s.changes) > 0
+		return nil
+	})
+	if err != nil {
+		logrus.Warn(err)
+	}
+	return staged
+}
+
+// jarChangeOrder orders the application of the staged changes
+// removals go first, so they free the file names for the other changes
+var jarChangeOrder = map[string]int{
+	JarRemove:  0,
+	JarDisable: 1,
+	JarEnable:  1,
+	JarInstall: 2,
+}
+
+// applyStagedJars applies the staged changes of the jars before the Minecraft Server starts
+// only called by start after going Starting and before launching, staging is refused meanwhile
+// failed changes stay staged with their uploads and are retried at the next start
+func (w *Wrapper) applyStagedJars() {
+	applied := false
+	err := w.readStage(func(s *jarStage) error {
+		if len(s.changes) == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, len(s.changes))
+		for key := range s.changes {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			oi, oj := jarChangeOrder[s.changes[keys[i]]], jarChangeOrder[s.changes[keys[j]]]
+			if oi != oj {
+				return oi < oj
+			}
+			return keys[i] < keys[j]
+		})
+
+		for _, key := range keys {
+			change := s.changes[key]
+			dir, file, ok := splitJarKey(key)
+			if !ok {
+				logrus.Warnf("discarding invalid staged jar %s", key)
+				delete(s.changes, key)
+				continue
+			}
+			path := filepath.Join(w.conf().Workingdir, dir, file)
+
+			var err error
+			switch change {
+			case JarInstall:
+				if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+					err = os.Rename(w.stagingPath(dir, file), path)
+				}
+			case JarEnable:
+				err = os.Rename(path, strings.TrimSuffix(path, disabledSuffix))
+			case JarDisable:
+				err = os.Rename(path, path+disabledSuffix)
+			case JarRemove:
+				if err = os.Remove(path); os.IsNotExist(err) {
+					err = nil
+				}
+			default:
+				logrus.Warnf("discarding unknown staged change %s of %s", change, key)
+				delete(s.changes, key)
+				continue
+			}
+
+			if err != nil {
+				w.publishErr(fmt.Sprintf("failed to %s %s, it stays staged: %v", change, key, err))
+				continue
+			}
+			logrus.Infof("%s %s of %s", change, key, w.Name())
+			delete(s.changes, key)
+		}
+		applied = len(s.changes) < len(keys)
+
+		if err := s.save(); err != nil {
+			return err
+		}
+		if len(s.changes) == 0 {
+			return os.RemoveAll(w.stagingPath())
+		}
+		return nil
+	})
+	if err != nil {
+		logrus.Errorf("failed to apply the staged jars: %v", err)
+	}
+	if applied {
+		w.publishPending(w.Pending())
+	}
+}
@@ -0,0 +1,299 @@
+// Package jars reads the metadata of plugin and mod jars
+package jars
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// types of the metadata
+const (
+	TypeBukkit = "bukkit"
+	TypePaper  = "paper"
+	TypeFabric = "fabric"
+	TypeForge  = "forge"
+)
+
+// maximum size of a metadata file, guards against zip bombs
+const maxDescriptorSize = 1 << 20
+
+// Meta the metadata of a plugin or mod
+type Meta struct {
+	Type        string   `json:"type"`
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Version     string   `json:"version"`
+	Description string   `json:"description,omitempty"`
+	Authors     []string `json:"authors"`
+	Depends     []string `json:"depends"`
+}
+
+// descriptors the metadata files in the order they are looked for
+// paper-plugin.yml is preferred, paper plugins can have both
+var descriptors = []struct {
+	file  string
+	parse func(content []byte, jar *zip.Reader) (*Meta, error)
+}{
+	{"paper-plugin.yml", parsePaper},
+	{"plugin.yml", parseBukkit},
+	{"fabric.mod.json", parseFabric},
+	{"META-INF/mods.toml", parseForge},
+}
+
+// ReadMeta reads the metadata of the jar
+// returns nil if the jar has no known metadata file
+func ReadMeta(path string) (*Meta, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	for _, d := range descriptors {
+		content, err := readFile(&r.Reader, d.file)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+
+		meta, err := d.parse(content, &r.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", d.file, err)
+		}
+		if meta.Authors == nil {
+			meta.Authors = make([]string, 0)
+		}
+		if meta.Depends == nil {
+			meta.Depends = make([]string, 0)
+		}
+		return meta, nil
+	}
+	return nil, nil
+}
+
+// readFile reads the file of the jar, nil if it doesn't exist
+func readFile(jar *zip.Reader, name string) ([]byte, error) {
+	for _, f := range jar.File {
+		if f.Name != name {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		content, err := ioutil.ReadAll(io.LimitReader(rc, maxDescriptorSize))
+		if err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
+	return nil, nil
+}
+
+// readConfig reads the content in the format with viper, used for toml
+func readConfig(format string, content []byte) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType(format)
+	if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// stringList returns the value as list of strings, a single string becomes a list
+func stringList(value interface{}) []string {
+	if s, ok := value.(string); ok {
+		if s == "" {
+			return nil
+		}
+		return []string{s}
+	}
+	return cast.ToStringSlice(value)
+}
+
+// lookup returns the value of the key ignoring the case
+// viper doesn't lower the keys of tables in arrays
+func lookup(m map[string]interface{}, key string) interface{} {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return nil
+}
+
+// plugin the fields of a plugin.yml and paper-plugin.yml
+// the version is a string, yaml would read 1.0 as number
+type plugin struct {
+	Name         string      `yaml:"name"`
+	Version      string      `yaml:"version"`
+	Description  string      `yaml:"description"`
+	Author       interface{} `yaml:"author"`
+	Authors      interface{} `yaml:"authors"`
+	Depend       interface{} `yaml:"depend"`
+	Dependencies interface{} `yaml:"dependencies"`
+}
+
+// meta returns the metadata of the plugin
+func (p plugin) meta(typ string) *Meta {
+	return &Meta{
+		Type:        typ,
+		Name:        p.Name,
+		Version:     p.Version,
+		Description: p.Description,
+		Authors:     append(stringList(p.Author), stringList(p.Authors)...),
+		Depends:     stringList(p.Depend),
+	}
+}
+
+// parseBukkit parses a plugin.yml
+func parseBukkit(content []byte, _ *zip.Reader) (*Meta, error) {
+	var p plugin
+	if err := yaml.Unmarshal(content, &p); err != nil {
+		return nil, err
+	}
+	return p.meta(TypeBukkit), nil
+}
+
+// parsePaper parses a paper-plugin.yml
+// the dependencies are a map of the plugins by load phase
+func parsePaper(content []byte, _ *zip.Reader) (*Meta, error) {
+	var p plugin
+	if err := yaml.Unmarshal(content, &p); err != nil {
+		return nil, err
+	}
+
+	meta := p.meta(TypePaper)
+	phases := cast.ToStringMap(p.Dependencies)
+	for _, phase := range []string{"server", "bootstrap"} {
+		for name, dep := range cast.ToStringMap(phases[phase]) {
+			if required, ok := cast.ToStringMap(dep)["required"]; !ok || cast.ToBool(required) {
+				meta.Depends = append(meta.Depends, name)
+			}
+		}
+	}
+	sort.Strings(meta.Depends)
+	return meta, nil
+}
+
+// parseFabric parses a fabric.mod.json
+// authors are either names or objects with a name
+func parseFabric(content []byte, _ *zip.Reader) (*Meta, error) {
+	var mod struct {
+		ID          string                 `json:"id"`
+		Name        string                 `json:"name"`
+		Version     string                 `json:"version"`
+		Description string                 `json:"description"`
+		Authors     []json.RawMessage      `json:"authors"`
+		Depends     map[string]interface{} `json:"depends"`
+	}
+	if err := json.Unmarshal(content, &mod); err != nil {
+		return nil, err
+	}
+
+	meta := &Meta{
+		Type:        TypeFabric,
+		ID:          mod.ID,
+		Name:        mod.Name,
+		Version:     mod.Version,
+		Description: mod.Description,
+	}
+	if meta.Name == "" {
+		meta.Name = mod.ID
+	}
+
+	for _, raw := range mod.Authors {
+		var author struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &author.Name); err != nil {
+			if err := json.Unmarshal(raw, &author); err != nil {
+				return nil, err
+			}
+		}
+		meta.Authors = append(meta.Authors, author.Name)
+	}
+
+	for id := range mod.Depends {
+		meta.Depends = append(meta.Depends, id)
+	}
+	sort.Strings(meta.Depends)
+
+	return meta, nil
+}
+
+// parseForge parses a META-INF/mods.toml, the first mod describes the jar
+// a version of ${file.jarVersion} is read from the manifest
+func parseForge(content []byte, jar *zip.Reader) (*Meta, error) {
+	v, err := readConfig("toml", content)
+	if err != nil {
+		return nil, err
+	}
+
+	mods := cast.ToSlice(v.Get("mods"))
+	if len(mods) == 0 {
+		return nil, fmt.Errorf("no mods")
+	}
+	mod := cast.ToStringMap(mods[0])
+
+	meta := &Meta{
+		Type:        TypeForge,
+		ID:          cast.ToString(lookup(mod, "modId")),
+		Name:        cast.ToString(lookup(mod, "displayName")),
+		Version:     cast.ToString(lookup(mod, "version")),
+		Description: strings.TrimSpace(cast.ToString(lookup(mod, "description"))),
+		Authors:     stringList(lookup(mod, "authors")),
+	}
+	if meta.Name == "" {
+		meta.Name = meta.ID
+	}
+
+	if meta.Version == "${file.jarVersion}" {
+		manifest, err := readFile(jar, "META-INF/MANIFEST.MF")
+		if err != nil {
+			return nil, err
+		}
+		meta.Version = manifestValue(manifest, "Implementation-Version")
+	}
+
+	for _, dep := range cast.ToSlice(v.Get("dependencies." + strings.ToLower(meta.ID))) {
+		d := cast.ToStringMap(dep)
+		id := cast.ToString(lookup(d, "modId"))
+		if id == "forge" || id == "minecraft" || id == "neoforge" {
+			continue
+		}
+		if mandatory := lookup(d, "mandatory"); mandatory != nil && !cast.ToBool(mandatory) {
+			continue
+		}
+		meta.Depends = append(meta.Depends, id)
+	}
+
+	return meta, nil
+}
+
+// manifestValue returns the value of the key of the manifest
+func manifestValue(manifest []byte, key string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		if value := strings.TrimPrefix(scanner.Text(), key+": "); value != scanner.Text() {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
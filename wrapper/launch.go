@@ -0,0 +1,288 @@
+package wrapper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// detectTimeout timeout of java -version
+const detectTimeout = 10 * time.Second
+
+// launchConfig config of how the Minecraft Server is launched
+type launchConfig struct {
+	Profile   string
+	Java      string
+	Script    string
+	Argsfiles []string
+}
+
+// aikarsFlags Aikar's G1 flags for HotSpot
+var aikarsFlags = []string{
+	"-XX:+UseG1GC",
+	"-XX:+ParallelRefProcEnabled",
+	"-XX:MaxGCPauseMillis=200",
+	"-XX:+UnlockExperimentalVMOptions",
+	"-XX:+DisableExplicitGC",
+	"-XX:+AlwaysPreTouch",
+	"-XX:G1HeapWastePercent=5",
+	"-XX:G1MixedGCCountTarget=4",
+	"-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1RSetUpdatingPauseTimePercent=5",
+	"-XX:SurvivorRatio=32",
+	"-XX:+PerfDisableSharedMem",
+	"-XX:MaxTenuringThreshold=1",
+	"-Dusing.aikars.flags=https://mcflags.emc.gs",
+	"-Daikars.new.flags=true",
+}
+
+// aikarsSmallHeapFlags Aikar's G1 sizing for heaps up to 12G
+var aikarsSmallHeapFlags = []string{
+	"-XX:G1NewSizePercent=30",
+	"-XX:G1MaxNewSizePercent=40",
+	"-XX:G1HeapRegionSize=8M",
+	"-XX:G1ReservePercent=20",
+	"-XX:InitiatingHeapOccupancyPercent=15",
+}
+
+// aikarsLargeHeapFlags Aikar's G1 sizing for heaps above 12G
+var aikarsLargeHeapFlags = []string{
+	"-XX:G1NewSizePercent=40",
+	"-XX:G1MaxNewSizePercent=50",
+	"-XX:G1HeapRegionSize=16M",
+	"-XX:G1ReservePercent=15",
+	"-XX:InitiatingHeapOccupancyPercent=20",
+}
+
+// zgcFlags flags of the Z garbage collector
+var zgcFlags = []string{
+	"-XX:+UseZGC",
+	"-XX:+AlwaysPreTouch",
+	"-XX:+DisableExplicitGC",
+	"-XX:+PerfDisableSharedMem",
+}
+
+// profileArgs returns the JVM args of the profile for the heap size in MB
+// a heap size of 0 leaves the heap to the JVM
+func profileArgs(profile LaunchProfile, heapSize int) []string {
+	var args []string
+	if heapSize > 0 {
+		args = append(args, fmt.Sprintf("-Xms%dM", heapSize), fmt.Sprintf("-Xmx%dM", heapSize))
+	}
+
+	switch profile {
+	case ProfileOpenJ9:
+		if heapSize > 0 {
+			args = append(args, fmt.Sprintf("-Xmns%dM", heapSize/2), fmt.Sprintf("-Xmnx%dM", heapSize*4/5))
+		}
+		args = append(args,
+			"-Xgc:concurrentScavenge",
+			"-Xgc:dnssExpectedTimeRatioMaximum=3",
+			"-Xgc:scvNoAdaptiveTenure",
+			"-Xdisableexplicitgc",
+			"-Xtune:virtualized",
+		)
+	case ProfileHotSpot:
+		args = append(args, aikarsFlags...)
+		if heapSize > 12*1024 {
+			args = append(args, aikarsLargeHeapFlags...)
+		} else {
+			args = append(args, aikarsSmallHeapFlags...)
+		}
+	case ProfileZGC:
+		args = append(args, zgcFlags...)
+	}
+
+	return args
+}
+
+// detectProfile detects the profile matching the JVM by its java -version
+func detectProfile(java string) (LaunchProfile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), detectTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, java, "-version").CombinedOutput()
+	if err != nil {
+		return LaunchProfile(0), fmt.Errorf("failed to detect the JVM of %s: %v", java, err)
+	}
+
+	if strings.Contains(string(output), "OpenJ9") || strings.Contains(string(output), "IBM J9") {
+		return ProfileOpenJ9, nil
+	}
+	return ProfileHotSpot, nil
+}
+
+// splitArgs splits the args like a shell
+// args are separated by whitespace, quotes and backslashes keep whitespace
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		arg     strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash in %q", s)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, s)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// splitAll splits each of the configured args
+func splitAll(items []string) ([]string, error) {
+	args := make([]string, 0, len(items))
+	for _, item := range items {
+		split, err := splitArgs(item)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, split...)
+	}
+	return args, nil
+}
+
+// quoteArgs joins the args, quoting args with whitespace or quotes
+func quoteArgs(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// javaPath returns the path of the java binary
+// a path is relative to the MSW like the working directory, a name is looked up in the PATH
+func javaPath(java string) (string, error) {
+	if !strings.ContainsRune(java, filepath.Separator) {
+		return java, nil
+	}
+	return filepath.Abs(java)
+}
+
+// jvmArgs returns the args of the profile followed by the configured JVM args
+// the profile auto is detected on every start, so an updated JVM is picked up
+func jvmArgs(c *instanceConfig) ([]string, error) {
+	profile := LaunchProfileFor(c.Launch.Profile)
+	if profile == ProfileAuto {
+		java, err := javaPath(c.Launch.Java)
+		if err != nil {
+			return nil, err
+		}
+		detected, err := detectProfile(java)
+		if err != nil {
+			return nil, err
+		}
+		logrus.Infof("detected %s JVM for %s", detected, c.Name)
+		profile = detected
+	}
+
+	extra, err := splitAll(c.JvmArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(profileArgs(profile, c.Heapsize), extra...), nil
+}
+
+// launchCmd builds the command starting the Minecraft Server
+// a script gets the JVM args by JDK_JAVA_OPTIONS and the configured java first in the PATH,
+// args files replace the -jar of the jar
+func (w *Wrapper) launchCmd() (*exec.Cmd, error) {
+	c := w.conf()
+
+	jvm, err := jvmArgs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	server, err := splitAll(c.ServerArgs)
+	if err != nil {
+		return nil, err
+	}
+	server = append([]string{"nogui"}, server...)
+
+	java, err := javaPath(c.Launch.Java)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmd *exec.Cmd
+	switch {
+	case c.Launch.Script != "":
+		script := c.Launch.Script
+		if !strings.ContainsRune(script, filepath.Separator) {
+			script = "." + string(filepath.Separator) + script
+		}
+
+		cmd = exec.Command(script, server...)
+		cmd.Env = append(os.Environ(), "JDK_JAVA_OPTIONS="+quoteArgs(jvm))
+		if dir := filepath.Dir(java); dir != "." {
+			cmd.Env = append(cmd.Env, "PATH="+dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+		}
+	case len(c.Launch.Argsfiles) > 0:
+		args := jvm
+		for _, file := range c.Launch.Argsfiles {
+			args = append(args, "@"+strings.TrimPrefix(file, "@"))
+		}
+		cmd = exec.Command(java, append(args, server...)...)
+	default:
+		args := append(jvm, "-jar", c.Jar)
+		cmd = exec.Command(java, append(args, server...)...)
+	}
+
+	cmd.Dir = c.Workingdir
+	logrus.Infof("Executing %s in %s", cmd.Args, cmd.Dir)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Pdeathsig: syscall.SIGTERM,
+		Setpgid:   true,
+	}
+
+	return cmd, nil
+}
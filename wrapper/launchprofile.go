@@ -0,0 +1,55 @@
+package wrapper
+
+import "fmt"
+
+// LaunchProfile enum of JVM launch profiles
+type LaunchProfile int
+
+// possible launch profiles
+const (
+	ProfileOpenJ9 LaunchProfile = iota + 1
+	ProfileHotSpot
+	ProfileZGC
+	ProfileCustom
+	ProfileAuto
+)
+
+var launchProfileMap = map[LaunchProfile]string{
+	ProfileOpenJ9:  "openj9",
+	ProfileHotSpot: "hotspot",
+	ProfileZGC:     "zgc",
+	ProfileCustom:  "custom",
+	ProfileAuto:    "auto",
+}
+
+func (p LaunchProfile) String() string {
+	if val, ok := launchProfileMap[p]; ok {
+		return val
+	}
+
+	return "unknown"
+}
+
+// LaunchProfileFor returns LaunchProfile for the given string
+// ignores errors
+func LaunchProfileFor(s string) LaunchProfile {
+	profile, _ := LaunchProfileForE(s)
+	return profile
+}
+
+// LaunchProfileForE returns LaunchProfile for the given string
+func LaunchProfileForE(s string) (LaunchProfile, error) {
+	for k, v := range launchProfileMap {
+		if v == s {
+			return k, nil
+		}
+	}
+
+	return LaunchProfile(0), fmt.Errorf("no known launch profile for %s", s)
+}
+
+// Validate validates that the value is a valide enum value
+func (p LaunchProfile) Validate() (ok bool) {
+	_, ok = launchProfileMap[p]
+	return
+}
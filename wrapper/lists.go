@@ -0,0 +1,409 @@
+package wrapper
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+)
+
+// files of the player lists in the working directory
+const (
+	whitelistFile     = "whitelist.json"
+	opsFile           = "ops.json"
+	bannedPlayersFile = "banned-players.json"
+	bannedIPsFile     = "banned-ips.json"
+	usercacheFile     = "usercache.json"
+)
+
+// banTimeFormat format of the created field of bans
+const banTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// errors of the player lists
+var (
+	ErrServerBusy    = errors.New("server is starting or stopping, try again later")
+	ErrUnknownPlayer = errors.New("unknown player")
+	ErrNotListed     = errors.New("not listed")
+	ErrInvalidEntry  = errors.New("invalid entry")
+)
+
+// playerNameRegexp allowed player names, prevents injecting further commands
+var playerNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_.]{1,16}$`)
+
+// listPath returns the path of the list file
+func (w *Wrapper) listPath(file string) string {
+	return filepath.Join(w.conf().Workingdir, file)
+}
+
+// readList reads the json list file into v
+// a missing file is an empty list
+func (w *Wrapper) readList(file string, v interface{}) error {
+	content, err := ioutil.ReadFile(w.listPath(file))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, v)
+}
+
+// writeList writes v to the json list file
+func (w *Wrapper) writeList(file string, v interface{}) error {
+	return writeJSONFile(w.listPath(file), v)
+}
+
+// writeJSONFile writes v to the json file
+// the file is replaced atomically
+func writeJSONFile(path string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// validateName validates a player name
+func validateName(name string) error {
+	if !playerNameRegexp.MatchString(name) {
+		return fmt.Errorf("%w: player name %q", ErrInvalidEntry, name)
+	}
+	return nil
+}
+
+// validateReason validates a ban reason
+func validateReason(reason string) error {
+	if strings.ContainsAny(reason, "\r\n") {
+		return fmt.Errorf("%w: reason must be a single line", ErrInvalidEntry)
+	}
+	return nil
+}
+
+// offlineUUID returns the uuid an offline mode server assigns to the player
+func offlineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// Usercache returns the players cached by the Minecraft Server
+func (w *Wrapper) Usercache() ([]model.CachedPlayer, error) {
+	players := make([]model.CachedPlayer, 0)
+	if err := w.readList(usercacheFile, &players); err != nil {
+		return nil, err
+	}
+	return players, nil
+}
+
+// ResolvePlayer resolves the name to the uuid by the usercache
+// if the server runs in offline mode, unknown players get their offline uuid
+func (w *Wrapper) ResolvePlayer(name string) (model.CachedPlayer, error) {
+	players, err := w.Usercache()
+	if err != nil {
+		return model.CachedPlayer{}, err
+	}
+
+	for _, p := range players {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+
+	if props, err := w.serverProperties(); err == nil && props["online-mode"] == "false" {
+		return model.CachedPlayer{Name: name, UUID: offlineUUID(name)}, nil
+	}
+
+	return model.CachedPlayer{}, fmt.Errorf("%w %s, the player has to join once", ErrUnknownPlayer, name)
+}
+
+// changeList applies a change of a list
+// while online, the command is sent, so the server keeps its state,
+// while offline, the file is edited
+func (w *Wrapper) changeList(cmd string, offline func() error) (string, error) {
+	switch w.CurrentState() {
+	case ServerOnline:
+		return w.sendServerCommand(cmd)
+	case ServerOffline:
+		w.listsMu.Lock()
+		defer w.listsMu.Unlock()
+		return "", offline()
+	default:
+		return "", ErrServerBusy
+	}
+}
+
+// Whitelist returns the whitelisted players
+func (w *Wrapper) Whitelist() ([]model.WhitelistEntry, error) {
+	list := make([]model.WhitelistEntry, 0)
+	if err := w.readList(whitelistFile, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// WhitelistAdd adds the player to the whitelist
+func (w *Wrapper) WhitelistAdd(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	return w.changeList("whitelist add "+name, func() error {
+		player, err := w.ResolvePlayer(name)
+		if err != nil {
+			return err
+		}
+
+		list, err := w.Whitelist()
+		if err != nil {
+			return err
+		}
+		for _, e := range list {
+			if e.UUID == player.UUID {
+				return nil
+			}
+		}
+
+		return w.writeList(whitelistFile, append(list, model.WhitelistEntry{UUID: player.UUID, Name: player.Name}))
+	})
+}
+
+// WhitelistRemove removes the player from the whitelist
+func (w *Wrapper) WhitelistRemove(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	return w.changeList("whitelist remove "+name, func() error {
+		list, err := w.Whitelist()
+		if err != nil {
+			return err
+		}
+		for i, e := range list {
+			if strings.EqualFold(e.Name, name) {
+				return w.writeList(whitelistFile, append(list[:i], list[i+1:]...))
+			}
+		}
+		return fmt.Errorf("%s %w on the whitelist", name, ErrNotListed)
+	})
+}
+
+// Ops returns the operators
+func (w *Wrapper) Ops() ([]model.Op, error) {
+	list := make([]model.Op, 0)
+	if err := w.readList(opsFile, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Op makes the player an operator
+// offline, the level is the op-permission-level of the server.properties
+func (w *Wrapper) Op(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	return w.changeList("op "+name, func() error {
+		player, err := w.ResolvePlayer(name)
+		if err != nil {
+			return err
+		}
+
+		list, err := w.Ops()
+		if err != nil {
+			return err
+		}
+		for _, e := range list {
+			if e.UUID == player.UUID {
+				return nil
+			}
+		}
+
+		level := 4
+		if props, err := w.serverProperties(); err == nil {
+			if l, err := strconv.Atoi(props["op-permission-level"]); err == nil {
+				level = l
+			}
+		}
+
+		return w.writeList(opsFile, append(list, model.Op{UUID: player.UUID, Name: player.Name, Level: level}))
+	})
+}
+
+// Deop removes the player from the operators
+func (w *Wrapper) Deop(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	return w.changeList("deop "+name, func() error {
+		list, err := w.Ops()
+		if err != nil {
+			return err
+		}
+		for i, e := range list {
+			if strings.EqualFold(e.Name, name) {
+				return w.writeList(opsFile, append(list[:i], list[i+1:]...))
+			}
+		}
+		return fmt.Errorf("%s %w as operator", name, ErrNotListed)
+	})
+}
+
+// BannedPlayers returns the banned players
+func (w *Wrapper) BannedPlayers() ([]model.BannedPlayer, error) {
+	list := make([]model.BannedPlayer, 0)
+	if err := w.readList(bannedPlayersFile, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Ban bans the player
+func (w *Wrapper) Ban(name, reason string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	if err := validateReason(reason); err != nil {
+		return "", err
+	}
+
+	return w.changeList(strings.TrimSpace("ban "+name+" "+reason), func() error {
+		player, err := w.ResolvePlayer(name)
+		if err != nil {
+			return err
+		}
+
+		list, err := w.BannedPlayers()
+		if err != nil {
+			return err
+		}
+		for _, e := range list {
+			if e.UUID == player.UUID {
+				return nil
+			}
+		}
+
+		if reason == "" {
+			reason = "Banned by an operator."
+		}
+
+		return w.writeList(bannedPlayersFile, append(list, model.BannedPlayer{
+			UUID:    player.UUID,
+			Name:    player.Name,
+			Created: time.Now().Format(banTimeFormat),
+			Source:  "Server",
+			Expires: "forever",
+			Reason:  reason,
+		}))
+	})
+}
+
+// Pardon unbans the player
+func (w *Wrapper) Pardon(name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	return w.changeList("pardon "+name, func() error {
+		list, err := w.BannedPlayers()
+		if err != nil {
+			return err
+		}
+		for i, e := range list {
+			if strings.EqualFold(e.Name, name) {
+				return w.writeList(bannedPlayersFile, append(list[:i], list[i+1:]...))
+			}
+		}
+		return fmt.Errorf("%s %w as banned", name, ErrNotListed)
+	})
+}
+
+// BannedIPs returns the banned ips
+func (w *Wrapper) BannedIPs() ([]model.BannedIP, error) {
+	list := make([]model.BannedIP, 0)
+	if err := w.readList(bannedIPsFile, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// BanIP bans the ip
+func (w *Wrapper) BanIP(ip, reason string) (string, error) {
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("%w: ip %q", ErrInvalidEntry, ip)
+	}
+	if err := validateReason(reason); err != nil {
+		return "", err
+	}
+
+	return w.changeList(strings.TrimSpace("ban-ip "+ip+" "+reason), func() error {
+		list, err := w.BannedIPs()
+		if err != nil {
+			return err
+		}
+		for _, e := range list {
+			if e.IP == ip {
+				return nil
+			}
+		}
+
+		if reason == "" {
+			reason = "Banned by an operator."
+		}
+
+		return w.writeList(bannedIPsFile, append(list, model.BannedIP{
+			IP:      ip,
+			Created: time.Now().Format(banTimeFormat),
+			Source:  "Server",
+			Expires: "forever",
+			Reason:  reason,
+		}))
+	})
+}
+
+// PardonIP unbans the ip
+func (w *Wrapper) PardonIP(ip string) (string, error) {
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("%w: ip %q", ErrInvalidEntry, ip)
+	}
+
+	return w.changeList("pardon-ip "+ip, func() error {
+		list, err := w.BannedIPs()
+		if err != nil {
+			return err
+		}
+		for i, e := range list {
+			if e.IP == ip {
+				return w.writeList(bannedIPsFile, append(list[:i], list[i+1:]...))
+			}
+		}
+		return fmt.Errorf("%s %w as banned", ip, ErrNotListed)
+	})
+}
@@ -15,6 +15,9 @@ var eventToRegexpMap = map[Event]*regexp.Regexp{
 	StopEvent:    regexp.MustCompile(`Stopping (.*) server`),
 }
 
+// savedRegexp matches the log line after the worlds have been saved
+var savedRegexp = regexp.MustCompile(`Saved the game`)
+
 // logLine parts of the minecraft server logs
 type logLine struct {
 	timestamp  string
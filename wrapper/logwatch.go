@@ -0,0 +1,60 @@
+package wrapper
+
+import (
+	"regexp"
+	"sync"
+)
+
+// logWatcher waits for a log line matching its regexp
+type logWatcher struct {
+	regexp *regexp.Regexp
+	match  chan string
+}
+
+// logWatchers set of active log watchers
+type logWatchers struct {
+	mu       sync.Mutex
+	watchers map[*logWatcher]bool
+}
+
+// watch registers a new watcher for the given regexp
+func (lw *logWatchers) watch(r *regexp.Regexp) *logWatcher {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if lw.watchers == nil {
+		lw.watchers = make(map[*logWatcher]bool)
+	}
+
+	watcher := &logWatcher{
+		regexp: r,
+		match:  make(chan string, 1),
+	}
+	lw.watchers[watcher] = true
+	return watcher
+}
+
+// unwatch removes the watcher
+func (lw *logWatchers) unwatch(watcher *logWatcher) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	delete(lw.watchers, watcher)
+}
+
+// notify notifies all watchers matching the log output
+func (lw *logWatchers) notify(output string) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	for watcher := range lw.watchers {
+		if !watcher.regexp.MatchString(output) {
+			continue
+		}
+
+		select {
+		case watcher.match <- output:
+		default:
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package wrapper
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// metricsConfig config of the metrics
+type metricsConfig struct {
+	Tps struct {
+		Command  string
+		Interval time.Duration
+	}
+}
+
+// regexps of the tick statistics printed by different servers
+var (
+	// Spigot and Paper: TPS from last 1m, 5m, 15m: 20.0, 20.0, 20.0
+	tpsRegexp = regexp.MustCompile(`TPS from last 1m, 5m, 15m: \*?([0-9.]+)`)
+	// Paper: ◴ 1.2/0.5/10.3, 1.3/0.5/12.0, 1.3/0.4/15.1
+	paperMsptRegexp = regexp.MustCompile(`◴ ([0-9.]+)/`)
+	// Forge: Overall: Mean tick time: 1.234 ms. Mean TPS: 20.000
+	forgeTpsRegexp = regexp.MustCompile(`Overall\s*: Mean tick time: ([0-9.]+) ms\. Mean TPS: ([0-9.]+)`)
+	// vanilla tick query: Average time per tick: 1.2ms (Target: 50.0ms)
+	tickQueryRegexp = regexp.MustCompile(`Average time per tick: ([0-9.]+)ms`)
+	// formatting codes of RCON responses
+	formatCodeRegexp = regexp.MustCompile(`§.`)
+)
+
+// metrics of the Wrapper and the Minecraft Server
+type metrics struct {
+	transitions *prometheus.CounterVec
+	restarts    *prometheus.CounterVec
+	crashes     prometheus.Counter
+	published   *prometheus.CounterVec
+
+	state   *prometheus.Desc
+	uptime  *prometheus.Desc
+	players *prometheus.Desc
+	tps     *prometheus.Desc
+	mspt    *prometheus.Desc
+	rss     *prometheus.Desc
+	cpu     *prometheus.Desc
+
+	mu          sync.Mutex
+	onlineSince time.Time
+	lastTps     float64
+	lastMspt    float64
+}
+
+// newMetrics initialises the metrics labeled with the instance as server,
+// Prometheus sets the instance label of the scraped target itself
+func newMetrics(instance string) *metrics {
+	labels := prometheus.Labels{"server": instance}
+
+	return &metrics{
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "msw_server_state_transitions_total",
+			Help:        "Number of state transitions of the Minecraft Server.",
+			ConstLabels: labels,
+		}, []string{"from", "to"}),
+		restarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "msw_server_restarts_total",
+			Help:        "Number of restarts of the Minecraft Server, by the restart policy or requested.",
+			ConstLabels: labels,
+		}, []string{"reason"}),
+		crashes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "msw_server_crashes_total",
+			Help:        "Number of crashes of the Minecraft Server.",
+			ConstLabels: labels,
+		}),
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "msw_messages_published_total",
+			Help:        "Number of messages published to the subscribers.",
+			ConstLabels: labels,
+		}, []string{"type"}),
+
+		state: prometheus.NewDesc("msw_server_state",
+			"Current state of the Minecraft Server, 1 for the current state.", []string{"state"}, labels),
+		uptime: prometheus.NewDesc("msw_server_uptime_seconds",
+			"Seconds since the Minecraft Server came online, 0 if it isn't online.", nil, labels),
+		players: prometheus.NewDesc("msw_players_online",
+			"Number of players online.", nil, labels),
+		tps: prometheus.NewDesc("msw_server_tps",
+			"Last parsed ticks per second of the Minecraft Server.", nil, labels),
+		mspt: prometheus.NewDesc("msw_server_mspt",
+			"Last parsed milliseconds per tick of the Minecraft Server.", nil, labels),
+		rss: prometheus.NewDesc("msw_server_resident_memory_bytes",
+			"Resident memory of the process group of the Minecraft Server.", nil, labels),
+		cpu: prometheus.NewDesc("msw_server_cpu_seconds_total",
+			"CPU time of the process group of the Minecraft Server.", nil, labels),
+
+		lastTps:  math.NaN(),
+		lastMspt: math.NaN(),
+	}
+}
+
+// online records the time the server came online
+func (m *metrics) online() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onlineSince = time.Now()
+}
+
+// offline resets the values only known while online
+func (m *metrics) offline() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.onlineSince = time.Time{}
+	m.lastTps = math.NaN()
+	m.lastMspt = math.NaN()
+}
+
+// parseTicks parses tick statistics of the output
+func (m *metrics) parseTicks(output string) {
+	output = formatCodeRegexp.ReplaceAllString(output, "")
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if match := tpsRegexp.FindStringSubmatch(output); match != nil {
+		m.lastTps = parseFloat(match[1])
+	}
+	if match := paperMsptRegexp.FindStringSubmatch(output); match != nil {
+		m.lastMspt = parseFloat(match[1])
+	}
+	if match := forgeTpsRegexp.FindStringSubmatch(output); match != nil {
+		m.lastMspt = parseFloat(match[1])
+		m.lastTps = parseFloat(match[2])
+	}
+	if match := tickQueryRegexp.FindStringSubmatch(output); match != nil {
+		m.lastMspt = parseFloat(match[1])
+		if m.lastMspt > 0 {
+			m.lastTps = math.Min(20, 1000/m.lastMspt)
+		}
+	}
+}
+
+// parseFloat parses the float, NaN if invalid
+func parseFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// pollTicks periodically sends the configured command printing the tick statistics until stopped
+// a response of RCON is parsed directly, the console output is parsed as log
+func (w *Wrapper) pollTicks(stop <-chan struct{}) {
+	c := w.conf().Metrics.Tps
+	if c.Command == "" || c.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		if w.CurrentState() != ServerOnline {
+			continue
+		}
+
+		output, err := w.sendServerCommand(c.Command)
+		if err != nil {
+			logrus.Debugf("failed to poll tps: %v", err)
+			continue
+		}
+		for _, line := range strings.Split(output, "\n") {
+			w.metrics.parseTicks(line)
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (w *Wrapper) Describe(ch chan<- *prometheus.Desc) {
+	w.metrics.transitions.Describe(ch)
+	w.metrics.restarts.Describe(ch)
+	w.metrics.crashes.Describe(ch)
+	w.metrics.published.Describe(ch)
+	ch <- w.metrics.state
+	ch <- w.metrics.uptime
+	ch <- w.metrics.players
+	ch <- w.metrics.tps
+	ch <- w.metrics.mspt
+	ch <- w.metrics.rss
+	ch <- w.metrics.cpu
+}
+
+// Collect implements prometheus.Collector
+func (w *Wrapper) Collect(ch chan<- prometheus.Metric) {
+	m := w.metrics
+	m.transitions.Collect(ch)
+	m.restarts.Collect(ch)
+	m.crashes.Collect(ch)
+	m.published.Collect(ch)
+
+	current := w.CurrentState()
+	for state, name := range statemap {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(m.state, prometheus.GaugeValue, value, name)
+	}
+
+	ch <- prometheus.MustNewConstMetric(m.players, prometheus.GaugeValue, float64(len(w.Players())))
+
+	m.mu.Lock()
+	uptime := 0.0
+	if !m.onlineSince.IsZero() {
+		uptime = time.Since(m.onlineSince).Seconds()
+	}
+	tps, mspt := m.lastTps, m.lastMspt
+	m.mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(m.uptime, prometheus.GaugeValue, uptime)
+	if !math.IsNaN(tps) {
+		ch <- prometheus.MustNewConstMetric(m.tps, prometheus.GaugeValue, tps)
+	}
+	if !math.IsNaN(mspt) {
+		ch <- prometheus.MustNewConstMetric(m.mspt, prometheus.GaugeValue, mspt)
+	}
+
+	if current == ServerOffline || w.console == nil {
+		return
+	}
+
+	// the whole process group, the pid may be the one of a start script
+	u, err := readGroupUsage(w.console.Pid())
+	if err != nil {
+		logrus.Debug(err)
+		return
+	}
+	if u.processes == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(m.rss, prometheus.GaugeValue, float64(u.rss))
+	ch <- prometheus.MustNewConstMetric(m.cpu, prometheus.CounterValue, u.cpu)
+}
@@ -1,7 +1,52 @@
 package model
 
+import "strings"
+
 // Command wich for the MSW
 type Command struct {
+	ID      string        `json:"id,omitempty"`
 	Target  CommandTarget `json:"target"`
 	Payload string        `json:"payload"`
+
+	// User and RemoteAddr identify the issuer of the command
+	User       string `json:"-"`
+	RemoteAddr string `json:"-"`
+
+	// Instance the name of the instance the command is for
+	Instance string `json:"-"`
+
+	// Reply receives the replies for the issuer of the command
+	// if nil, replies get published to all subscribers
+	Reply func(*Message) `json:"-"`
+}
+
+// Issuer returns the name of the issuer of the command
+// commands without user are issued by the MSW itself
+func (c *Command) Issuer() string {
+	if c.User == "" {
+		return "msw"
+	}
+	return c.User
+}
+
+// Result creates the message with the result of the command
+// commands with an id get a RESULT message, all others a LOG message
+func (c *Command) Result(output string, err error) *Message {
+	if c.ID != "" {
+		res := Result{
+			ID:      c.ID,
+			Success: err == nil,
+			Output:  output,
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		return &Message{Type: TypeResult, Payload: res}
+	}
+
+	lines := output
+	if err != nil {
+		lines = strings.TrimPrefix(lines+"\n"+err.Error(), "\n")
+	}
+	return &Message{Type: TypeLog, Payload: lines}
 }
@@ -13,16 +13,23 @@ type CommandTarget int
 const (
 	TargetWrapper CommandTarget = iota + 1
 	TargetServer
+	// requests answered by the web server, they don't reach the Minecraft Server
+	TargetComplete
+	TargetHistory
 )
 
 var targetToString = map[CommandTarget]string{
-	TargetWrapper: "WRAPPER",
-	TargetServer:  "SERVER",
+	TargetWrapper:  "WRAPPER",
+	TargetServer:   "SERVER",
+	TargetComplete: "COMPLETE",
+	TargetHistory:  "HISTORY",
 }
 
 var targetForString = map[string]CommandTarget{
-	"WRAPPER": TargetWrapper,
-	"SERVER":  TargetServer,
+	"WRAPPER":  TargetWrapper,
+	"SERVER":   TargetServer,
+	"COMPLETE": TargetComplete,
+	"HISTORY":  TargetHistory,
 }
 
 func (t CommandTarget) String() string {
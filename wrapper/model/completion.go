@@ -0,0 +1,14 @@
+package model
+
+// Completion payload of the suggestions completing the input of a console
+type Completion struct {
+	ID          string   `json:"id,omitempty"`
+	Input       string   `json:"input"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// History payload of the command history of a user
+type History struct {
+	ID       string   `json:"id,omitempty"`
+	Commands []string `json:"commands"`
+}
@@ -0,0 +1,41 @@
+package model
+
+// CachedPlayer an entry of the usercache.json
+type CachedPlayer struct {
+	Name      string `json:"name"`
+	UUID      string `json:"uuid"`
+	ExpiresOn string `json:"expiresOn,omitempty"`
+}
+
+// WhitelistEntry an entry of the whitelist.json
+type WhitelistEntry struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// Op an entry of the ops.json
+type Op struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit"`
+}
+
+// BannedPlayer an entry of the banned-players.json
+type BannedPlayer struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+// BannedIP an entry of the banned-ips.json
+type BannedIP struct {
+	IP      string `json:"ip"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
@@ -1,7 +1,8 @@
 package model
 
 // Message wich the MSW sends
+// the payload is a string or one of the typed event payloads
 type Message struct {
 	Type    MessageType `json:"type"`
-	Payload string      `json:"payload"`
+	Payload interface{} `json:"payload"`
 }
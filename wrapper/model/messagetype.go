@@ -14,18 +14,63 @@ const (
 	TypeLog MessageType = iota + 1
 	TypeError
 	TypeState
+	TypeRestart
+	TypeJoin
+	TypeLeave
+	TypeChat
+	TypeDeath
+	TypeAdvancement
+	TypeSay
+	TypePlayers
+	TypeResult
+	TypeStats
+	TypePending
+	TypeComplete
+	TypeHistory
+	TypeStatus
+	TypeQuery
 )
 
 var typeToString = map[MessageType]string{
-	TypeLog:   "LOG",
-	TypeError: "ERROR",
-	TypeState: "STATE",
+	TypeLog:         "LOG",
+	TypeError:       "ERROR",
+	TypeState:       "STATE",
+	TypeRestart:     "RESTART",
+	TypeJoin:        "JOIN",
+	TypeLeave:       "LEAVE",
+	TypeChat:        "CHAT",
+	TypeDeath:       "DEATH",
+	TypeAdvancement: "ADVANCEMENT",
+	TypeSay:         "SAY",
+	TypePlayers:     "PLAYERS",
+	TypeResult:      "RESULT",
+	TypeStats:       "STATS",
+	TypePending:     "PENDING",
+	TypeComplete:    "COMPLETE",
+	TypeHistory:     "HISTORY",
+	TypeStatus:      "STATUS",
+	TypeQuery:       "QUERY",
 }
 
 var typeForString = map[string]MessageType{
-	"LOG":   TypeLog,
-	"ERROR": TypeError,
-	"STATE": TypeState,
+	"LOG":         TypeLog,
+	"ERROR":       TypeError,
+	"STATE":       TypeState,
+	"RESTART":     TypeRestart,
+	"JOIN":        TypeJoin,
+	"LEAVE":       TypeLeave,
+	"CHAT":        TypeChat,
+	"DEATH":       TypeDeath,
+	"ADVANCEMENT": TypeAdvancement,
+	"SAY":         TypeSay,
+	"PLAYERS":     TypePlayers,
+	"RESULT":      TypeResult,
+	"STATS":       TypeStats,
+	"PENDING":     TypePending,
+	"COMPLETE":    TypeComplete,
+	"HISTORY":     TypeHistory,
+	"STATUS":      TypeStatus,
+	"QUERY":       TypeQuery,
 }
 
 func (t MessageType) String() string {
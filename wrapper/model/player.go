@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Player a player online on the Minecraft Server
+type Player struct {
+	Name     string    `json:"name"`
+	UUID     string    `json:"uuid,omitempty"`
+	IP       string    `json:"ip,omitempty"`
+	JoinTime time.Time `json:"joinTime"`
+}
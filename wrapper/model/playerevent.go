@@ -0,0 +1,39 @@
+package model
+
+// PlayerJoin payload of a player joining the game
+type PlayerJoin struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"`
+	IP   string `json:"ip,omitempty"`
+}
+
+// PlayerLeave payload of a player leaving the game
+type PlayerLeave struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"`
+}
+
+// Chat payload of a chat message
+type Chat struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Death payload of a player death
+type Death struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// Advancement payload of a player making an advancement
+type Advancement struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Advancement string `json:"advancement"`
+}
+
+// Say payload of a /say message
+type Say struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Query full stat of the Minecraft Server queried by the Query protocol
+type Query struct {
+	Available bool      `json:"available"`
+	Checked   time.Time `json:"checked"`
+	Error     string    `json:"error,omitempty"`
+	MOTD      string    `json:"motd,omitempty"`
+	GameType  string    `json:"gameType,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	Software  string    `json:"software,omitempty"`
+	Map       string    `json:"map,omitempty"`
+	Online    int       `json:"online"`
+	Max       int       `json:"max"`
+	Players   []string  `json:"players"`
+	Plugins   []string  `json:"plugins"`
+}
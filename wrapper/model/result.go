@@ -0,0 +1,9 @@
+package model
+
+// Result payload of the result of a command with id
+type Result struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Stats resource usage of the Minecraft Server process group
+type Stats struct {
+	Time       time.Time `json:"time"`
+	Processes  int       `json:"processes"`
+	CPU        float64   `json:"cpu"`
+	RSS        uint64    `json:"rss"`
+	Threads    int       `json:"threads"`
+	FDs        int       `json:"fds"`
+	ReadRate   float64   `json:"readRate"`
+	WriteRate  float64   `json:"writeRate"`
+	ReadBytes  uint64    `json:"readBytes"`
+	WriteBytes uint64    `json:"writeBytes"`
+}
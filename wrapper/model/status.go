@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Status status of the Minecraft Server queried by Server List Ping
+type Status struct {
+	Reachable bool           `json:"reachable"`
+	Checked   time.Time      `json:"checked"`
+	Latency   float64        `json:"latency"`
+	Error     string         `json:"error,omitempty"`
+	Version   string         `json:"version,omitempty"`
+	Protocol  int            `json:"protocol,omitempty"`
+	MOTD      string         `json:"motd,omitempty"`
+	Online    int            `json:"online"`
+	Max       int            `json:"max"`
+	Sample    []SamplePlayer `json:"sample"`
+}
+
+// SamplePlayer a player of the sample of the online players
+type SamplePlayer struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid"`
+}
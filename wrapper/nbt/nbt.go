@@ -0,0 +1,320 @@
+// Package nbt implements a decoder for the Named Binary Tag format of Minecraft
+package nbt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// tag types
+const (
+	tagEnd byte = iota
+	tagByte
+	tagShort
+	tagInt
+	tagLong
+	tagFloat
+	tagDouble
+	tagByteArray
+	tagString
+	tagList
+	tagCompound
+	tagIntArray
+	tagLongArray
+)
+
+const (
+	// maximum nesting of lists and compounds
+	maxDepth = 512
+	// maximum length of an array or list, guards allocations of corrupt files
+	maxLength = 1 << 24
+)
+
+// ErrInvalid is returned for malformed data
+var ErrInvalid = errors.New("invalid nbt")
+
+// Compound a compound tag
+// the values are int8, int16, int32, int64, float32, float64, string,
+// []byte, []int32, []int64, []interface{} and Compound
+type Compound map[string]interface{}
+
+// decoder reads the tags of the data
+type decoder struct {
+	r io.Reader
+}
+
+// Decode decodes the uncompressed data and returns the name and value of the root compound
+func Decode(r io.Reader) (string, Compound, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	typ, err := d.byte()
+	if err != nil {
+		return "", nil, err
+	}
+	if typ != tagCompound {
+		return "", nil, fmt.Errorf("%w: root is tag %d, not a compound", ErrInvalid, typ)
+	}
+
+	name, err := d.string()
+	if err != nil {
+		return "", nil, err
+	}
+	root, err := d.compound(0)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, root, nil
+}
+
+// ReadFile decodes the file, compressed with gzip, zlib or uncompressed
+func ReadFile(path string) (Compound, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = bytes.NewReader(content)
+	switch {
+	case len(content) >= 2 && content[0] == 0x1f && content[1] == 0x8b:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	case len(content) >= 1 && content[0] == 0x78:
+		z, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer z.Close()
+		r = z
+	}
+
+	_, root, err := Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return root, nil
+}
+
+// Int returns the integer value of the key, 0 if it isn't a number
+func (c Compound) Int(key string) int64 {
+	switch v := c[key].(type) {
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	case float32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// Float returns the float value of the key, 0 if it isn't a number
+func (c Compound) Float(key string) float64 {
+	switch v := c[key].(type) {
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return float64(c.Int(key))
+}
+
+// String returns the string value of the key, empty if it isn't a string
+func (c Compound) String(key string) string {
+	s, _ := c[key].(string)
+	return s
+}
+
+// List returns the list value of the key, nil if it isn't a list
+func (c Compound) List(key string) []interface{} {
+	l, _ := c[key].([]interface{})
+	return l
+}
+
+// Compound returns the compound value of the key, nil if it isn't a compound
+func (c Compound) Compound(key string) Compound {
+	v, _ := c[key].(Compound)
+	return v
+}
+
+// read reads exactly len(buf) bytes
+func (d *decoder) read(buf []byte) error {
+	_, err := io.ReadFull(d.r, buf)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readValues reads the big endian values of an array
+func (d *decoder) readValues(values interface{}) error {
+	err := binary.Read(d.r, binary.BigEndian, values)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (d *decoder) byte() (byte, error) {
+	var buf [1]byte
+	err := d.read(buf[:])
+	return buf[0], err
+}
+
+func (d *decoder) short() (int16, error) {
+	var buf [2]byte
+	err := d.read(buf[:])
+	return int16(binary.BigEndian.Uint16(buf[:])), err
+}
+
+func (d *decoder) int() (int32, error) {
+	var buf [4]byte
+	err := d.read(buf[:])
+	return int32(binary.BigEndian.Uint32(buf[:])), err
+}
+
+func (d *decoder) long() (int64, error) {
+	var buf [8]byte
+	err := d.read(buf[:])
+	return int64(binary.BigEndian.Uint64(buf[:])), err
+}
+
+// length reads the length of an array or list
+func (d *decoder) length() (int, error) {
+	n, err := d.int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > maxLength {
+		return 0, fmt.Errorf("%w: length %d", ErrInvalid, n)
+	}
+	return int(n), nil
+}
+
+// string reads a string prefixed with its unsigned length
+// the modified UTF-8 of Java only differs for NUL and supplementary characters
+func (d *decoder) string() (string, error) {
+	n, err := d.short()
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, uint16(n))
+	if err := d.read(buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// compound reads the tags of a compound until the end tag
+func (d *decoder) compound(depth int) (Compound, error) {
+	c := make(Compound)
+	for {
+		typ, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		if typ == tagEnd {
+			return c, nil
+		}
+
+		name, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		if c[name], err = d.value(typ, depth+1); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// value reads the payload of a tag of the type
+func (d *decoder) value(typ byte, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("%w: nested too deep", ErrInvalid)
+	}
+
+	switch typ {
+	case tagByte:
+		b, err := d.byte()
+		return int8(b), err
+	case tagShort:
+		return d.short()
+	case tagInt:
+		return d.int()
+	case tagLong:
+		return d.long()
+	case tagFloat:
+		i, err := d.int()
+		return math.Float32frombits(uint32(i)), err
+	case tagDouble:
+		l, err := d.long()
+		return math.Float64frombits(uint64(l)), err
+	case tagString:
+		return d.string()
+	case tagByteArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		return buf, d.read(buf)
+	case tagIntArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int32, n)
+		return values, d.readValues(values)
+	case tagLongArray:
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int64, n)
+		return values, d.readValues(values)
+	case tagList:
+		elem, err := d.byte()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.length()
+		if err != nil {
+			return nil, err
+		}
+		if elem == tagEnd && n > 0 {
+			return nil, fmt.Errorf("%w: list of end tags", ErrInvalid)
+		}
+
+		values := make([]interface{}, 0)
+		for i := 0; i < n; i++ {
+			v, err := d.value(elem, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case tagCompound:
+		return d.compound(depth)
+	}
+
+	return nil, fmt.Errorf("%w: unknown tag %d", ErrInvalid, typ)
+}
@@ -0,0 +1,224 @@
+package nbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// builder writes big endian nbt data
+type builder struct {
+	bytes.Buffer
+}
+
+func (b *builder) byte(v byte) *builder {
+	b.WriteByte(v)
+	return b
+}
+
+func (b *builder) short(v int16) *builder {
+	binary.Write(b, binary.BigEndian, v) //nolint:errcheck
+	return b
+}
+
+func (b *builder) int(v int32) *builder {
+	binary.Write(b, binary.BigEndian, v) //nolint:errcheck
+	return b
+}
+
+func (b *builder) long(v int64) *builder {
+	binary.Write(b, binary.BigEndian, v) //nolint:errcheck
+	return b
+}
+
+func (b *builder) string(s string) *builder {
+	b.short(int16(len(s)))
+	b.WriteString(s)
+	return b
+}
+
+// tag writes the type and name of a tag
+func (b *builder) tag(typ byte, name string) *builder {
+	return b.byte(typ).string(name)
+}
+
+// player a compound like the player data with every tag type
+func player() []byte {
+	b := new(builder)
+	b.tag(tagCompound, "")
+	b.tag(tagByte, "OnGround").byte(1)
+	b.tag(tagShort, "Fire").short(-20)
+	b.tag(tagInt, "XpLevel").int(30)
+	b.tag(tagLong, "UUIDMost").long(-6684867342478735599)
+	b.tag(tagFloat, "Health").int(int32(math.Float32bits(20)))
+	b.tag(tagDouble, "X").long(int64(math.Float64bits(-12.5)))
+	b.tag(tagString, "Dimension").string("minecraft:overworld")
+	b.tag(tagByteArray, "Bytes").int(3).byte(1).byte(2).byte(0xff)
+	b.tag(tagIntArray, "UUID").int(2).int(-1).int(7)
+	b.tag(tagLongArray, "Longs").int(1).long(math.MaxInt64)
+	b.tag(tagList, "Pos").byte(tagDouble).int(2).long(int64(math.Float64bits(1))).long(int64(math.Float64bits(2)))
+	b.tag(tagList, "Empty").byte(tagEnd).int(0)
+	b.tag(tagList, "Inventory").byte(tagCompound).int(1)
+	b.tag(tagString, "id").string("minecraft:diamond")
+	b.tag(tagByte, "Count").byte(64)
+	b.byte(tagEnd)
+	b.tag(tagCompound, "abilities")
+	b.tag(tagByte, "flying").byte(0)
+	b.byte(tagEnd)
+	b.byte(tagEnd)
+	return b.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	name, root, err := Decode(bytes.NewReader(player()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if name != "" {
+		t.Errorf("name = %q, want empty", name)
+	}
+
+	want := Compound{
+		"OnGround":  int8(1),
+		"Fire":      int16(-20),
+		"XpLevel":   int32(30),
+		"UUIDMost":  int64(-6684867342478735599),
+		"Health":    float32(20),
+		"X":         float64(-12.5),
+		"Dimension": "minecraft:overworld",
+		"Bytes":     []byte{1, 2, 0xff},
+		"UUID":      []int32{-1, 7},
+		"Longs":     []int64{math.MaxInt64},
+		"Pos":       []interface{}{float64(1), float64(2)},
+		"Empty":     []interface{}{},
+		"Inventory": []interface{}{Compound{"id": "minecraft:diamond", "Count": int8(64)}},
+		"abilities": Compound{"flying": int8(0)},
+	}
+	if !reflect.DeepEqual(root, want) {
+		t.Errorf("Decode() = %#v, want %#v", root, want)
+	}
+}
+
+func TestCompoundAccessors(t *testing.T) {
+	_, root, err := Decode(bytes.NewReader(player()))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"Int(byte)", root.Int("OnGround"), int64(1)},
+		{"Int(short)", root.Int("Fire"), int64(-20)},
+		{"Int(int)", root.Int("XpLevel"), int64(30)},
+		{"Int(double)", root.Int("X"), int64(-12)},
+		{"Int(string)", root.Int("Dimension"), int64(0)},
+		{"Int(missing)", root.Int("missing"), int64(0)},
+		{"Float(float)", root.Float("Health"), float64(20)},
+		{"Float(int)", root.Float("XpLevel"), float64(30)},
+		{"String", root.String("Dimension"), "minecraft:overworld"},
+		{"String(int)", root.String("XpLevel"), ""},
+		{"List", len(root.List("Pos")), 2},
+		{"List(compound)", root.List("abilities") == nil, true},
+		{"Compound", root.Compound("abilities").Int("flying"), int64(0)},
+		{"Compound(missing)", root.Compound("missing") == nil, true},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	nested := new(builder).tag(tagCompound, "")
+	for i := 0; i <= maxDepth; i++ {
+		nested.tag(tagCompound, "c")
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, io.ErrUnexpectedEOF},
+		{"root no compound", new(builder).tag(tagInt, "").int(1).Bytes(), ErrInvalid},
+		{"truncated name", new(builder).byte(tagCompound).short(5).byte('a').Bytes(), io.ErrUnexpectedEOF},
+		{"missing end", new(builder).tag(tagCompound, "").tag(tagByte, "b").byte(1).Bytes(), io.ErrUnexpectedEOF},
+		{"truncated int", new(builder).tag(tagCompound, "").tag(tagInt, "i").short(1).Bytes(), io.ErrUnexpectedEOF},
+		{"truncated byte array", new(builder).tag(tagCompound, "").tag(tagByteArray, "a").int(4).byte(1).Bytes(), io.ErrUnexpectedEOF},
+		{"truncated int array", new(builder).tag(tagCompound, "").tag(tagIntArray, "a").int(2).int(1).Bytes(), io.ErrUnexpectedEOF},
+		{"empty int array payload", new(builder).tag(tagCompound, "").tag(tagIntArray, "a").int(2).Bytes(), io.ErrUnexpectedEOF},
+		{"truncated long array", new(builder).tag(tagCompound, "").tag(tagLongArray, "a").int(1).int(1).Bytes(), io.ErrUnexpectedEOF},
+		{"truncated list", new(builder).tag(tagCompound, "").tag(tagList, "l").byte(tagInt).int(2).int(1).Bytes(), io.ErrUnexpectedEOF},
+		{"negative length", new(builder).tag(tagCompound, "").tag(tagByteArray, "a").int(-1).Bytes(), ErrInvalid},
+		{"too long", new(builder).tag(tagCompound, "").tag(tagLongArray, "a").int(maxLength + 1).Bytes(), ErrInvalid},
+		{"too long list", new(builder).tag(tagCompound, "").tag(tagList, "l").byte(tagByte).int(maxLength + 1).Bytes(), ErrInvalid},
+		{"list of end tags", new(builder).tag(tagCompound, "").tag(tagList, "l").byte(tagEnd).int(1).Bytes(), ErrInvalid},
+		{"unknown tag", new(builder).tag(tagCompound, "").tag(13, "x").Bytes(), ErrInvalid},
+		{"unknown list tag", new(builder).tag(tagCompound, "").tag(tagList, "l").byte(42).int(1).Bytes(), ErrInvalid},
+		{"nested too deep", nested.Bytes(), ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := Decode(bytes.NewReader(tt.data)); !errors.Is(err, tt.want) {
+			t.Errorf("Decode(%s) error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nbt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var gz, z bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(player()) //nolint:errcheck
+	gw.Close()
+	zw := zlib.NewWriter(&z)
+	zw.Write(player()) //nolint:errcheck
+	zw.Close()
+
+	for name, data := range map[string][]byte{
+		"uncompressed": player(),
+		"gzip":         gz.Bytes(),
+		"zlib":         z.Bytes(),
+	} {
+		path := filepath.Join(dir, name+".dat")
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		root, err := ReadFile(path)
+		if err != nil {
+			t.Errorf("ReadFile(%s) error = %v", name, err)
+			continue
+		}
+		if root.String("Dimension") != "minecraft:overworld" {
+			t.Errorf("ReadFile(%s) = %v", name, root)
+		}
+	}
+
+	path := filepath.Join(dir, "truncated.dat")
+	if err := ioutil.WriteFile(path, gz.Bytes()[:len(gz.Bytes())/2], 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadFile(path); err == nil {
+		t.Error("ReadFile(truncated) error = nil, want an error")
+	}
+}
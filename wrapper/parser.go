@@ -0,0 +1,129 @@
+package wrapper
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/momper14/msw/wrapper/model"
+)
+
+// regexps of the player related log lines
+var (
+	uuidRegexp        = regexp.MustCompile(`^UUID of player (\w+) is ([0-9a-f-]+)$`)
+	loginRegexp       = regexp.MustCompile(`^(\w+)\[/(.+):\d+\] logged in with entity id`)
+	joinRegexp        = regexp.MustCompile(`^(\w+) joined the game$`)
+	leaveRegexp       = regexp.MustCompile(`^(\w+) left the game$`)
+	chatRegexp        = regexp.MustCompile(`^<(\w+)> (.*)$`)
+	sayRegexp         = regexp.MustCompile(`^\[([^\]\s:]+)\] (.*)$`)
+	advancementRegexp = regexp.MustCompile(`^(\w+) has (made the advancement|completed the challenge|reached the goal) \[(.+)\]$`)
+	deathRegexp       = regexp.MustCompile(`^(\w+) (was (slain|shot|killed|blown up|fireballed|impaled|stung|squashed|squished|struck|pricked|poked|roasted|skewered|obliterated|pummeled|doomed|burnt|frozen)|drowned|died|blew up|burned to death|went up in flames|walked into|tried to swim in lava|starved to death|suffocated|fell|hit the ground too hard|withered away|froze to death|experienced kinetic energy|discovered the floor was lava|didn't want to live|left the confines of this world)\b`)
+)
+
+// advancementKinds maps the log phrase to the kind of advancement
+var advancementKinds = map[string]string{
+	"made the advancement":    "advancement",
+	"completed the challenge": "challenge",
+	"reached the goal":        "goal",
+}
+
+// eventParser parses log lines to typed player events
+type eventParser struct {
+	mu    sync.Mutex
+	uuids map[string]string
+	ips   map[string]string
+}
+
+// newEventParser initialises a new eventParser
+func newEventParser() *eventParser {
+	return &eventParser{
+		uuids: make(map[string]string),
+		ips:   make(map[string]string),
+	}
+}
+
+// uuid returns the known uuid of the player
+func (p *eventParser) uuid(name string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.uuids[name]
+}
+
+// ip returns the ip the player logged in with
+func (p *eventParser) ip(name string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.ips[name]
+}
+
+// parse parses the logline to a message
+// returns nil if the line isn't a player event
+func (p *eventParser) parse(ll *logLine) *model.Message {
+	output := ll.output
+	if ll.level != "INFO" {
+		return nil
+	}
+
+	if m := uuidRegexp.FindStringSubmatch(output); m != nil {
+		p.mu.Lock()
+		p.uuids[m[1]] = m[2]
+		p.mu.Unlock()
+		return nil
+	}
+
+	if m := loginRegexp.FindStringSubmatch(output); m != nil {
+		p.mu.Lock()
+		p.ips[m[1]] = m[2]
+		p.mu.Unlock()
+		return nil
+	}
+
+	if m := chatRegexp.FindStringSubmatch(output); m != nil {
+		return &model.Message{
+			Type:    model.TypeChat,
+			Payload: model.Chat{Name: m[1], Message: m[2]},
+		}
+	}
+
+	if m := sayRegexp.FindStringSubmatch(output); m != nil {
+		return &model.Message{
+			Type:    model.TypeSay,
+			Payload: model.Say{Name: m[1], Message: m[2]},
+		}
+	}
+
+	if m := joinRegexp.FindStringSubmatch(output); m != nil {
+		return &model.Message{
+			Type:    model.TypeJoin,
+			Payload: model.PlayerJoin{Name: m[1], UUID: p.uuid(m[1]), IP: p.ip(m[1])},
+		}
+	}
+
+	if m := leaveRegexp.FindStringSubmatch(output); m != nil {
+		return &model.Message{
+			Type:    model.TypeLeave,
+			Payload: model.PlayerLeave{Name: m[1], UUID: p.uuid(m[1])},
+		}
+	}
+
+	if m := advancementRegexp.FindStringSubmatch(output); m != nil {
+		return &model.Message{
+			Type: model.TypeAdvancement,
+			Payload: model.Advancement{
+				Name:        m[1],
+				Kind:        advancementKinds[m[2]],
+				Advancement: m[3],
+			},
+		}
+	}
+
+	if m := deathRegexp.FindStringSubmatch(output); m != nil && ll.threadName == "Server thread" {
+		return &model.Message{
+			Type:    model.TypeDeath,
+			Payload: model.Death{Name: m[1], Message: output},
+		}
+	}
+
+	return nil
+}
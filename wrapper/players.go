@@ -0,0 +1,100 @@
+package wrapper
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+)
+
+// roster set of players online on the Minecraft Server
+type roster struct {
+	mu      sync.RWMutex
+	players map[string]model.Player
+}
+
+// newRoster initialises a new roster
+func newRoster() *roster {
+	return &roster{
+		players: make(map[string]model.Player),
+	}
+}
+
+// join adds the player to the roster
+func (r *roster) join(e model.PlayerJoin) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.players[e.Name] = model.Player{
+		Name:     e.Name,
+		UUID:     e.UUID,
+		IP:       e.IP,
+		JoinTime: time.Now(),
+	}
+}
+
+// leave removes the player from the roster
+func (r *roster) leave(e model.PlayerLeave) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.players, e.Name)
+}
+
+// clear removes all players from the roster
+// returns if the roster changed
+func (r *roster) clear() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.players) == 0 {
+		return false
+	}
+
+	r.players = make(map[string]model.Player)
+	return true
+}
+
+// list returns the players sorted by join time
+func (r *roster) list() []model.Player {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	players := make([]model.Player, 0, len(r.players))
+	for _, p := range r.players {
+		players = append(players, p)
+	}
+
+	sort.Slice(players, func(i, j int) bool { return players[i].JoinTime.Before(players[j].JoinTime) })
+	return players
+}
+
+// Players returns the players online on the Minecraft Server
+func (w *Wrapper) Players() []model.Player {
+	return w.roster.list()
+}
+
+// updatePlayers updates the roster by the player event
+// and publishes the changed roster
+func (w *Wrapper) updatePlayers(msg *model.Message) {
+	switch e := msg.Payload.(type) {
+	case model.PlayerJoin:
+		w.roster.join(e)
+	case model.PlayerLeave:
+		w.roster.leave(e)
+	default:
+		return
+	}
+
+	w.recordSession(msg)
+	w.publishPlayers()
+}
+
+// publishPlayers publishes the players online
+func (w *Wrapper) publishPlayers() {
+	w.publish(&model.Message{
+		Type:    model.TypePlayers,
+		Payload: w.Players(),
+	})
+}
@@ -0,0 +1,139 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/momper14/msw/wrapper/properties"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidProperties is returned if changes of the server.properties are invalid
+var ErrInvalidProperties = errors.New("invalid properties")
+
+// Property a key value pair of the server.properties
+type Property struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// Live is set if a running server can apply changes without restart
+	Live bool `json:"live"`
+}
+
+// PropertiesUpdate result of updating the server.properties
+type PropertiesUpdate struct {
+	Changed         []string `json:"changed"`
+	RestartRequired bool     `json:"restartRequired"`
+	Restarted       bool     `json:"restarted"`
+}
+
+// propertiesFile returns the path of the server.properties
+func (w *Wrapper) propertiesFile() string {
+	return filepath.Join(w.conf().Workingdir, "server.properties")
+}
+
+// serverProperties reads the server.properties of the Minecraft Server
+func (w *Wrapper) serverProperties() (map[string]string, error) {
+	p, err := properties.Load(w.propertiesFile())
+	if err != nil {
+		return nil, err
+	}
+	return p.Map(), nil
+}
+
+// ServerProperties returns the properties of the server.properties in file order
+func (w *Wrapper) ServerProperties() ([]Property, error) {
+	p, err := properties.Load(w.propertiesFile())
+	if err != nil {
+		return nil, err
+	}
+
+	props := make([]Property, 0)
+	for _, key := range p.Keys() {
+		value, _ := p.Get(key)
+		rule, _ := properties.RuleFor(key)
+		props = append(props, Property{Key: key, Value: value, Live: rule.Command != nil})
+	}
+	return props, nil
+}
+
+// UpdateProperties validates the changes and writes them to the server.properties
+// changes a running server can apply are sent as commands,
+// if restart is set, the server is restarted if any change requires it
+func (w *Wrapper) UpdateProperties(changes map[string]string, restart bool) (*PropertiesUpdate, error) {
+	var errs []string
+	for key, value := range changes {
+		if err := properties.Validate(key, value); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProperties, strings.Join(errs, "; "))
+	}
+
+	w.propertiesMu.Lock()
+	defer w.propertiesMu.Unlock()
+
+	p, err := properties.Load(w.propertiesFile())
+	if err != nil {
+		return nil, err
+	}
+
+	update := &PropertiesUpdate{Changed: make([]string, 0)}
+	var live []string
+	for key, value := range changes {
+		if old, ok := p.Get(key); ok && old == value {
+			continue
+		}
+		p.Set(key, value)
+		update.Changed = append(update.Changed, key)
+
+		rule, _ := properties.RuleFor(key)
+		if rule.Command != nil {
+			live = append(live, rule.Command(value))
+		} else {
+			update.RestartRequired = true
+		}
+	}
+	sort.Strings(update.Changed)
+
+	if len(update.Changed) == 0 {
+		return update, nil
+	}
+
+	if err := p.Save(w.propertiesFile()); err != nil {
+		return nil, err
+	}
+
+	switch w.CurrentState() {
+	case ServerOffline:
+		update.RestartRequired = false
+		return update, nil
+	case ServerStarting, ServerStopping:
+		update.RestartRequired = true
+		return update, nil
+	}
+
+	for _, cmd := range live {
+		if _, err := w.sendServerCommand(cmd); err != nil {
+			logrus.Warnf("failed to apply %q: %v", cmd, err)
+			update.RestartRequired = true
+		}
+	}
+
+	if restart && update.RestartRequired {
+		update.Restarted = true
+		go func() {
+			if err := w.Restart(); err != nil {
+				logrus.Error(err)
+				w.publishLog(err.Error())
+			}
+		}()
+	}
+
+	return update, nil
+}
@@ -0,0 +1,301 @@
+// Package properties reads and writes Java properties files like the server.properties
+// while keeping comments and the order of the keys
+package properties
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// line a logical line of a properties file
+// a logical line can span multiple physical lines
+type line struct {
+	raw    string
+	key    string
+	value  string
+	isProp bool
+	dirty  bool
+}
+
+// Properties a parsed properties file
+type Properties struct {
+	lines []*line
+	index map[string]*line
+}
+
+// New initialises empty Properties
+func New() *Properties {
+	return &Properties{
+		index: make(map[string]*line),
+	}
+}
+
+// Load loads the properties file
+func Load(path string) (*Properties, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Parse parses properties
+func Parse(r io.Reader) (*Properties, error) {
+	p := New()
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		raw := scanner.Text()
+		logical := strings.TrimLeft(raw, " \t\f")
+
+		if logical == "" || logical[0] == '#' || logical[0] == '!' {
+			p.lines = append(p.lines, &line{raw: raw})
+			continue
+		}
+
+		// join continuation lines
+		for continues(logical) && scanner.Scan() {
+			next := scanner.Text()
+			raw += "\n" + next
+			logical = logical[:len(logical)-1] + strings.TrimLeft(next, " \t\f")
+		}
+
+		key, value := split(logical)
+		l := &line{
+			raw:    raw,
+			key:    unescape(key),
+			value:  unescape(value),
+			isProp: true,
+		}
+		p.lines = append(p.lines, l)
+		p.index[l.key] = l
+	}
+
+	return p, scanner.Err()
+}
+
+// continues checks if the line ends with an odd number of backslashes
+func continues(s string) bool {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// split splits the logical line into key and the escaped value
+func split(s string) (string, string) {
+	i := 0
+	for ; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '=' || s[i] == ':' || s[i] == ' ' || s[i] == '\t' || s[i] == '\f' {
+			break
+		}
+	}
+	if i > len(s) {
+		i = len(s)
+	}
+
+	key := s[:i]
+	rest := strings.TrimLeft(s[i:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescape resolves the escape sequences of a key or value
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i == len(s)-1 {
+			b.WriteByte(c)
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if r, ok := unicodeEscape(s, i); ok {
+				i += 4
+				// characters outside the BMP are escaped as surrogate pair
+				if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+					if r2, ok := unicodeEscape(s, i+2); ok {
+						if dec := utf16.DecodeRune(r, r2); dec != unicode.ReplacementChar {
+							r = dec
+							i += 6
+						}
+					}
+				}
+				b.WriteRune(r)
+				continue
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// unicodeEscape parses the 4 hex digits following the u at i
+func unicodeEscape(s string, i int) (rune, bool) {
+	if i+4 >= len(s) {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(r), true
+}
+
+// escape escapes a key or value, non ascii characters are written as \uXXXX
+func escape(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == '=' || r == ':' || r == '#' || r == '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == ' ' && (isKey || i == 0):
+			b.WriteString(`\ `)
+		case r < 0x20 || r > 0x7e:
+			if r > 0xffff {
+				r1, r2 := utf16.EncodeRune(r)
+				fmt.Fprintf(&b, `\u%04X\u%04X`, r1, r2)
+				continue
+			}
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Get returns the value of the key
+func (p *Properties) Get(key string) (string, bool) {
+	l, ok := p.index[key]
+	if !ok {
+		return "", false
+	}
+	return l.value, true
+}
+
+// Set sets the value of the key
+// new keys are appended at the end
+func (p *Properties) Set(key, value string) {
+	if l, ok := p.index[key]; ok {
+		if l.value != value {
+			l.value = value
+			l.dirty = true
+		}
+		return
+	}
+
+	l := &line{key: key, value: value, isProp: true, dirty: true}
+	p.lines = append(p.lines, l)
+	p.index[key] = l
+}
+
+// Keys returns the keys in file order
+func (p *Properties) Keys() []string {
+	keys := make([]string, 0, len(p.index))
+	for _, l := range p.lines {
+		if l.isProp && p.index[l.key] == l {
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Map returns the properties as map
+func (p *Properties) Map() map[string]string {
+	m := make(map[string]string, len(p.index))
+	for k, l := range p.index {
+		m[k] = l.value
+	}
+	return m
+}
+
+// WriteTo writes the properties
+// unchanged lines are written as they were read
+func (p *Properties) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	for _, l := range p.lines {
+		if l.dirty {
+			buf.WriteString(escape(l.key, true))
+			buf.WriteByte('=')
+			buf.WriteString(escape(l.value, false))
+		} else {
+			buf.WriteString(l.raw)
+		}
+		buf.WriteByte('\n')
+	}
+
+	return buf.WriteTo(w)
+}
+
+// Save writes the properties to the file
+// the file is replaced atomically
+func (p *Properties) Save(path string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := p.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
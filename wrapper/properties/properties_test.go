@@ -0,0 +1,214 @@
+package properties
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// serverProperties a server.properties with comments, blank lines and continuation lines
+const serverProperties = `#Minecraft server properties
+#Mon Mar 15 10:30:45 UTC 2021
+
+! another comment
+enable-jmx-monitoring=false
+rcon.port=25575
+level-seed=
+gamemode=survival
+motd=A \u00A7aMinecraft \
+    Server
+  leading-space = value with spaces
+colon:value
+space value
+escaped\=key=a\:b\\c
+tabs=\ta\tb
+`
+
+func TestParse(t *testing.T) {
+	p, err := Parse(strings.NewReader(serverProperties))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string]string{
+		"enable-jmx-monitoring": "false",
+		"rcon.port":             "25575",
+		"level-seed":            "",
+		"gamemode":              "survival",
+		"motd":                  "A §aMinecraft Server",
+		"leading-space":         "value with spaces",
+		"colon":                 "value",
+		"space":                 "value",
+		"escaped=key":           `a:b\c`,
+		"tabs":                  "\ta\tb",
+	}
+	if got := p.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Map() = %q, want %q", got, want)
+	}
+
+	keys := []string{"enable-jmx-monitoring", "rcon.port", "level-seed", "gamemode", "motd", "leading-space", "colon", "space", "escaped=key", "tabs"}
+	if got := p.Keys(); !reflect.DeepEqual(got, keys) {
+		t.Errorf("Keys() = %q, want %q", got, keys)
+	}
+}
+
+func TestParseEdgeCases(t *testing.T) {
+	tests := []struct {
+		in   string
+		want map[string]string
+	}{
+		{"", map[string]string{}},
+		{"# only a comment\n\n", map[string]string{}},
+		{"key", map[string]string{"key": ""}},
+		{"key=", map[string]string{"key": ""}},
+		{"key==value", map[string]string{"key": "=value"}},
+		{"key=a=b:c", map[string]string{"key": "a=b:c"}},
+		{"key=first\nkey=second", map[string]string{"key": "second"}},
+		// a continuation line isn't a comment
+		{"key=a\\\n#b", map[string]string{"key": "a#b"}},
+		// an even number of backslashes doesn't continue
+		{"key=a\\\\\nnext=b", map[string]string{"key": `a\`, "next": "b"}},
+		// a comment doesn't continue
+		{"#comment\\\nkey=value", map[string]string{"key": "value"}},
+		// invalid unicode escapes are kept as they are
+		{`key=\u00`, map[string]string{"key": "u00"}},
+		{`key=\uXYZW`, map[string]string{"key": "uXYZW"}},
+		{`key=\u00e4\u00F6`, map[string]string{"key": "äö"}},
+		{`key=\uD83D\uDE00`, map[string]string{"key": "\U0001F600"}},
+		// unknown escapes drop the backslash
+		{`key=\q`, map[string]string{"key": "q"}},
+		{"key=\xff", map[string]string{"key": "\xff"}},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(strings.NewReader(tt.in))
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if got := p.Map(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTooLong(t *testing.T) {
+	in := "key=" + strings.Repeat("a", 1<<20)
+	if _, err := Parse(strings.NewReader(in)); err == nil {
+		t.Error("Parse() error = nil, want an error for a too long line")
+	}
+}
+
+func TestWriteToUnchanged(t *testing.T) {
+	p, err := Parse(strings.NewReader(serverProperties))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var b bytes.Buffer
+	if _, err := p.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if b.String() != serverProperties {
+		t.Errorf("WriteTo() =\n%s\nwant\n%s", b.String(), serverProperties)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	p, err := Parse(strings.NewReader(serverProperties))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	changes := map[string]string{
+		"gamemode":    "creative",
+		"motd":        "Ä \U0001F600 server: #1!\n",
+		"level-seed":  " leading space",
+		"tabs":        "\ta\tb",
+		"new key":     `back\slash`,
+		"escaped=key": "",
+	}
+	for key, value := range changes {
+		p.Set(key, value)
+	}
+
+	var b bytes.Buffer
+	if _, err := p.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	out := b.String()
+
+	// comments, blank lines and unchanged lines are kept
+	for _, line := range []string{"#Minecraft server properties\n", "\n! another comment\n", "rcon.port=25575\n", "colon:value\n", "space value\n"} {
+		if !strings.Contains(out, line) {
+			t.Errorf("WriteTo() lost %q:\n%s", line, out)
+		}
+	}
+	// unchanged values aren't rewritten
+	if !strings.Contains(out, "\ntabs=\\ta\\tb\n") {
+		t.Errorf("WriteTo() rewrote the unchanged tabs:\n%s", out)
+	}
+
+	reparsed, err := Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got, want := reparsed.Map(), p.Map(); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %q, want %q", got, want)
+	}
+
+	keys := []string{"enable-jmx-monitoring", "rcon.port", "level-seed", "gamemode", "motd", "leading-space", "colon", "space", "escaped=key", "tabs", "new key"}
+	if got := reparsed.Keys(); !reflect.DeepEqual(got, keys) {
+		t.Errorf("Keys() = %q, want %q", got, keys)
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "properties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "server.properties")
+	if err := ioutil.WriteFile(path, []byte(serverProperties), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	p.Set("gamemode", "creative")
+	if err := p.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want the mode of the replaced file", info.Mode().Perm())
+	}
+
+	saved, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(serverProperties, "gamemode=survival", "gamemode=creative", 1); string(saved) != want {
+		t.Errorf("saved =\n%s\nwant\n%s", saved, want)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Save() left %d files, want only the properties", len(files))
+	}
+}
@@ -0,0 +1,146 @@
+package properties
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rule validates the value of a known key
+type Rule struct {
+	validate func(string) error
+
+	// Command applies the value to a running server
+	// if nil, the change needs a restart
+	Command func(string) string
+}
+
+// intRange allows integers within min and max
+func intRange(min, max int) func(string) error {
+	return func(s string) error {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("%q is no integer", s)
+		}
+		if i < min || i > max {
+			return fmt.Errorf("%d out of range %d-%d", i, min, max)
+		}
+		return nil
+	}
+}
+
+// oneOf allows only the given values
+func oneOf(values ...string) func(string) error {
+	return func(s string) error {
+		for _, v := range values {
+			if s == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", s, strings.Join(values, ", "))
+	}
+}
+
+// enum allows the names and, as older servers write them, their indexes
+func enum(names ...string) func(string) error {
+	return func(s string) error {
+		if _, ok := enumName(names, s); !ok {
+			return fmt.Errorf("%q is not one of %s or 0-%d", s, strings.Join(names, ", "), len(names)-1)
+		}
+		return nil
+	}
+}
+
+// enumName returns the name of the value which is a name or an index
+func enumName(names []string, s string) (string, bool) {
+	for i, name := range names {
+		if s == name || s == strconv.Itoa(i) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+var (
+	gamemodes    = []string{"survival", "creative", "adventure", "spectator"}
+	difficulties = []string{"peaceful", "easy", "normal", "hard"}
+)
+
+// boolean allows true and false
+var boolean = oneOf("true", "false")
+
+// port allows valid tcp and udp ports
+var port = intRange(1, 65535)
+
+// rules the rules of the known keys of the server.properties
+var rules = map[string]Rule{
+	"server-port":                       {validate: port},
+	"query.port":                        {validate: port},
+	"rcon.port":                         {validate: port},
+	"gamemode":                          {validate: enum(gamemodes...)},
+	"difficulty":                        {validate: enum(difficulties...), Command: difficultyCommand},
+	"view-distance":                     {validate: intRange(3, 32)},
+	"simulation-distance":               {validate: intRange(3, 32)},
+	"max-players":                       {validate: intRange(0, 2147483647)},
+	"spawn-protection":                  {validate: intRange(0, 2147483647)},
+	"max-world-size":                    {validate: intRange(1, 29999984)},
+	"op-permission-level":               {validate: intRange(0, 4)},
+	"function-permission-level":         {validate: intRange(1, 4)},
+	"network-compression-threshold":     {validate: intRange(-1, 2147483647)},
+	"max-tick-time":                     {validate: intRange(-1, 2147483647)},
+	"rate-limit":                        {validate: intRange(0, 2147483647)},
+	"player-idle-timeout":               {validate: intRange(0, 2147483647)},
+	"entity-broadcast-range-percentage": {validate: intRange(10, 1000)},
+	"white-list":                        {validate: boolean, Command: onOff("whitelist")},
+	"enforce-whitelist":                 {validate: boolean},
+	"online-mode":                       {validate: boolean},
+	"pvp":                               {validate: boolean},
+	"hardcore":                          {validate: boolean},
+	"allow-flight":                      {validate: boolean},
+	"allow-nether":                      {validate: boolean},
+	"spawn-monsters":                    {validate: boolean},
+	"spawn-animals":                     {validate: boolean},
+	"spawn-npcs":                        {validate: boolean},
+	"generate-structures":               {validate: boolean},
+	"enable-command-block":              {validate: boolean},
+	"enable-rcon":                       {validate: boolean},
+	"enable-query":                      {validate: boolean},
+	"enable-status":                     {validate: boolean},
+	"force-gamemode":                    {validate: boolean},
+}
+
+// difficultyCommand sets the difficulty by its name, the command doesn't accept indexes
+func difficultyCommand(v string) string {
+	name, _ := enumName(difficulties, v)
+	return "difficulty " + name
+}
+
+// onOff creates a command switching on or off
+func onOff(cmd string) func(string) string {
+	return func(v string) string {
+		if v == "true" {
+			return cmd + " on"
+		}
+		return cmd + " off"
+	}
+}
+
+// RuleFor returns the rule of the key
+func RuleFor(key string) (Rule, bool) {
+	r, ok := rules[key]
+	return r, ok
+}
+
+// Validate validates the value of the key
+// unknown keys are always valid
+func Validate(key, value string) error {
+	r, ok := rules[key]
+	if !ok || r.validate == nil {
+		return nil
+	}
+
+	if err := r.validate(value); err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	return nil
+}
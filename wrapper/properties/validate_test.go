@@ -0,0 +1,63 @@
+package properties
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"server-port", "25565", true},
+		{"server-port", "0", false},
+		{"server-port", "65536", false},
+		{"server-port", "port", false},
+		{"view-distance", "3", true},
+		{"view-distance", "2", false},
+		{"pvp", "true", true},
+		{"pvp", "yes", false},
+		{"gamemode", "survival", true},
+		{"gamemode", "3", true},
+		{"gamemode", "4", false},
+		{"gamemode", "Survival", false},
+		{"difficulty", "hard", true},
+		{"difficulty", "0", true},
+		{"difficulty", "-1", false},
+		{"network-compression-threshold", "-1", true},
+		{"unknown-key", "anything", true},
+	}
+
+	for _, tt := range tests {
+		if err := Validate(tt.key, tt.value); (err == nil) != tt.valid {
+			t.Errorf("Validate(%q, %q) error = %v, want valid %v", tt.key, tt.value, err, tt.valid)
+		}
+	}
+}
+
+func TestRuleCommand(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  string
+	}{
+		{"difficulty", "hard", "difficulty hard"},
+		{"difficulty", "1", "difficulty easy"},
+		{"white-list", "true", "whitelist on"},
+		{"white-list", "false", "whitelist off"},
+	}
+
+	for _, tt := range tests {
+		rule, ok := RuleFor(tt.key)
+		if !ok || rule.Command == nil {
+			t.Errorf("RuleFor(%q) has no command", tt.key)
+			continue
+		}
+		if got := rule.Command(tt.value); got != tt.want {
+			t.Errorf("%s command of %q = %q, want %q", tt.key, tt.value, got, tt.want)
+		}
+	}
+
+	if rule, _ := RuleFor("server-port"); rule.Command != nil {
+		t.Error("server-port has a command, want a restart")
+	}
+}
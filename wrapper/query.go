@@ -0,0 +1,172 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/momper14/msw/wrapper/query"
+	"github.com/sirupsen/logrus"
+)
+
+// errQueryDisabled is returned if the query isn't enabled in the server.properties
+var errQueryDisabled = errors.New("query is not enabled in server.properties")
+
+// queryConfig config of the Query client
+type queryConfig struct {
+	Enabled  bool
+	Host     string
+	Port     int
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// queryCache keeps the last full stat queried by the Query protocol
+type queryCache struct {
+	mu   sync.Mutex
+	stat model.Query
+}
+
+// newQueryCache initialises a queryCache without a stat
+func newQueryCache() *queryCache {
+	return &queryCache{stat: unavailable(time.Time{}, nil)}
+}
+
+// unavailable returns the stat of an unavailable query checked at the time
+func unavailable(checked time.Time, err error) model.Query {
+	stat := model.Query{Checked: checked, Players: make([]string, 0), Plugins: make([]string, 0)}
+	if err != nil {
+		stat.Error = err.Error()
+	}
+	return stat
+}
+
+// get returns the last stat
+func (c *queryCache) get() model.Query {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stat
+}
+
+// set stores the stat and reports if it changed, ignoring the time of the check
+func (c *queryCache) set(stat model.Query) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.stat
+	c.stat = stat
+	prev.Checked, stat.Checked = time.Time{}, time.Time{}
+	return !reflect.DeepEqual(prev, stat)
+}
+
+// queryAddr returns the address of the query port configured in the server.properties
+// mc.query.host and mc.query.port take precedence
+func (w *Wrapper) queryAddr() (string, error) {
+	c := w.conf().Query
+
+	props, err := w.serverProperties()
+	if err != nil {
+		return "", err
+	}
+	if props["enable-query"] != "true" {
+		return "", errQueryDisabled
+	}
+
+	host, port := c.Host, c.Port
+	if host == "" {
+		host = props["server-ip"]
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if port == 0 {
+		port, _ = strconv.Atoi(props["query.port"])
+	}
+	if port == 0 {
+		port = 25565
+	}
+
+	return net.JoinHostPort(host, fmt.Sprint(port)), nil
+}
+
+// fullStat queries the full stat of the Minecraft Server
+func (w *Wrapper) fullStat() model.Query {
+	addr, err := w.queryAddr()
+	if err != nil {
+		return unavailable(time.Now(), err)
+	}
+
+	s, err := query.FullStat(addr, w.conf().Query.Timeout)
+	if err != nil {
+		return unavailable(time.Now(), err)
+	}
+
+	return model.Query{
+		Available: true,
+		Checked:   time.Now(),
+		MOTD:      s.MOTD,
+		GameType:  s.GameType,
+		Version:   s.Version,
+		Software:  s.Software,
+		Map:       s.Map,
+		Online:    s.NumPlayers,
+		Max:       s.MaxPlayers,
+		Players:   s.Players,
+		Plugins:   s.Plugins,
+	}
+}
+
+// pollQuery periodically queries the full stat of the online Minecraft Server
+// and publishes changes of it until stopped
+func (w *Wrapper) pollQuery(stop <-chan struct{}) {
+	c := w.conf().Query
+	if !c.Enabled || c.Interval <= 0 {
+		w.updateQuery(unavailable(time.Time{}, nil))
+		return
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		if w.CurrentState() != ServerOnline {
+			w.updateQuery(unavailable(time.Now(), nil))
+			continue
+		}
+
+		stat := w.fullStat()
+		if !stat.Available {
+			logrus.Debugf("failed to query %s: %s", w.Name(), stat.Error)
+		}
+		w.updateQuery(stat)
+	}
+}
+
+// updateQuery stores the stat and publishes it if it changed
+func (w *Wrapper) updateQuery(stat model.Query) {
+	if !w.query.set(stat) {
+		return
+	}
+
+	w.publish(&model.Message{
+		Type:    model.TypeQuery,
+		Payload: stat,
+	})
+}
+
+// Query returns the last full stat queried by the Query protocol
+func (w *Wrapper) Query() model.Query {
+	return w.query.get()
+}
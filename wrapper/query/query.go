@@ -0,0 +1,204 @@
+// Package query implements a client for the GameSpy4 Query protocol of the Minecraft Server
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// packet types
+const (
+	typeStat      byte = 0x00
+	typeHandshake byte = 0x09
+)
+
+const (
+	// maximum size of a received packet
+	maxPacketSize = 65507
+	// session ids only use the lower 4 bits of each byte
+	sessionMask = 0x0F0F0F0F
+)
+
+var (
+	// magic prefix of the requests
+	magic = []byte{0xFE, 0xFD}
+	// padding of the full stat after the key values
+	keyValuesStart = []byte("splitnum\x00\x80\x00")
+	// marker of the players after the key values
+	playersStart = []byte("\x01player_\x00\x00")
+)
+
+// ErrInvalidPacket is returned for a malformed response
+var ErrInvalidPacket = errors.New("invalid packet")
+
+// Stat the full stat of a Minecraft Server
+type Stat struct {
+	// MOTD the hostname, which is the MOTD of the server
+	MOTD       string
+	GameType   string
+	GameID     string
+	Version    string
+	Map        string
+	NumPlayers int
+	MaxPlayers int
+	HostPort   int
+	HostIP     string
+	// Software the server software, empty for vanilla servers
+	Software string
+	Plugins  []string
+	Players  []string
+	// KeyValues all key values of the response
+	KeyValues map[string]string
+}
+
+// client a connection to the query port with a session
+type client struct {
+	conn    net.Conn
+	session int32
+}
+
+// FullStat requests the full stat of the Minecraft Server
+func FullStat(addr string, timeout time.Duration) (*Stat, error) {
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	c := &client{conn: conn, session: rand.Int31() & sessionMask}
+	token, err := c.handshake()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload bytes.Buffer
+	//nolint:errcheck
+	binary.Write(&payload, binary.BigEndian, token)
+	// the padding requests the full instead of the basic stat
+	payload.Write([]byte{0, 0, 0, 0})
+
+	data, err := c.request(typeStat, payload.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return parseFullStat(data)
+}
+
+// handshake requests the challenge token of the session
+func (c *client) handshake() (int32, error) {
+	data, err := c.request(typeHandshake, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	token, err := strconv.ParseInt(string(bytes.TrimRight(data, "\x00")), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: challenge token: %v", ErrInvalidPacket, err)
+	}
+	return int32(token), nil
+}
+
+// request sends a request and returns the payload of the response
+func (c *client) request(typ byte, payload []byte) ([]byte, error) {
+	var req bytes.Buffer
+	req.Write(magic)
+	req.WriteByte(typ)
+	//nolint:errcheck
+	binary.Write(&req, binary.BigEndian, c.session)
+	req.Write(payload)
+
+	if _, err := c.conn.Write(req.Bytes()); err != nil {
+		return nil, err
+	}
+
+	resp := make([]byte, maxPacketSize)
+	n, err := c.conn.Read(resp)
+	if err != nil {
+		return nil, err
+	}
+	resp = resp[:n]
+
+	if len(resp) < 5 || resp[0] != typ {
+		return nil, fmt.Errorf("%w: unexpected response", ErrInvalidPacket)
+	}
+	if int32(binary.BigEndian.Uint32(resp[1:5])) != c.session {
+		return nil, fmt.Errorf("%w: session mismatch", ErrInvalidPacket)
+	}
+	return resp[5:], nil
+}
+
+// parseFullStat parses the payload of a full stat response
+func parseFullStat(data []byte) (*Stat, error) {
+	if !bytes.HasPrefix(data, keyValuesStart) {
+		return nil, fmt.Errorf("%w: missing key values", ErrInvalidPacket)
+	}
+	data = data[len(keyValuesStart):]
+
+	end := bytes.Index(data, playersStart)
+	if end < 0 {
+		return nil, fmt.Errorf("%w: missing players", ErrInvalidPacket)
+	}
+
+	kv := make(map[string]string)
+	fields := strings.Split(string(data[:end]), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == "" {
+			break
+		}
+		kv[fields[i]] = fields[i+1]
+	}
+
+	players := make([]string, 0)
+	for _, name := range strings.Split(string(data[end+len(playersStart):]), "\x00") {
+		if name == "" {
+			break
+		}
+		players = append(players, name)
+	}
+
+	stat := &Stat{
+		MOTD:      kv["hostname"],
+		GameType:  kv["gametype"],
+		GameID:    kv["game_id"],
+		Version:   kv["version"],
+		Map:       kv["map"],
+		HostIP:    kv["hostip"],
+		Players:   players,
+		KeyValues: kv,
+	}
+	stat.NumPlayers, _ = strconv.Atoi(kv["numplayers"])
+	stat.MaxPlayers, _ = strconv.Atoi(kv["maxplayers"])
+	stat.HostPort, _ = strconv.Atoi(kv["hostport"])
+	stat.Software, stat.Plugins = parsePlugins(kv["plugins"])
+
+	return stat, nil
+}
+
+// parsePlugins parses the plugins of the full stat
+// Paper on 1.20.1-R0.1-SNAPSHOT: WorldEdit 7.2.15; LuckPerms 5.4.102
+func parsePlugins(s string) (string, []string) {
+	plugins := make([]string, 0)
+
+	software, list := s, ""
+	if i := strings.Index(s, ": "); i >= 0 {
+		software, list = s[:i], s[i+2:]
+	}
+
+	for _, plugin := range strings.Split(list, ";") {
+		if plugin = strings.TrimSpace(plugin); plugin != "" {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return strings.TrimSpace(software), plugins
+}
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// challengeToken token handed out by the fake server
+const challengeToken = 9513307
+
+// fullStatPayload builds the payload of a full stat response
+func fullStatPayload(kv [][2]string, players []string) []byte {
+	var b bytes.Buffer
+	b.Write(keyValuesStart)
+	for _, pair := range kv {
+		b.WriteString(pair[0] + "\x00" + pair[1] + "\x00")
+	}
+	b.WriteByte(0)
+	b.Write(playersStart)
+	for _, player := range players {
+		b.WriteString(player + "\x00")
+	}
+	b.WriteByte(0)
+	return b.Bytes()
+}
+
+// fakeServer answers the handshake and full stat requests with the payload
+func fakeServer(t *testing.T, payload []byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, maxPacketSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			req := buf[:n]
+			if n < 7 || !bytes.HasPrefix(req, magic) {
+				continue
+			}
+			typ, session := req[2], req[3:7]
+
+			var resp bytes.Buffer
+			resp.WriteByte(typ)
+			resp.Write(session)
+			switch typ {
+			case typeHandshake:
+				resp.WriteString("9513307\x00")
+			case typeStat:
+				if n != 15 || int32(binary.BigEndian.Uint32(req[7:11])) != challengeToken {
+					continue
+				}
+				resp.Write(payload)
+			default:
+				continue
+			}
+			conn.WriteTo(resp.Bytes(), addr) //nolint:errcheck
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestFullStat(t *testing.T) {
+	addr := fakeServer(t, fullStatPayload([][2]string{
+		{"hostname", "A Minecraft Server"},
+		{"gametype", "SMP"},
+		{"game_id", "MINECRAFT"},
+		{"version", "1.16.5"},
+		{"plugins", ""},
+		{"map", "world"},
+		{"numplayers", "2"},
+		{"maxplayers", "20"},
+		{"hostport", "25565"},
+		{"hostip", "127.0.0.1"},
+	}, []string{"Steve", "Alex"}))
+
+	stat, err := FullStat(addr, time.Second)
+	if err != nil {
+		t.Fatalf("FullStat() error = %v", err)
+	}
+
+	want := &Stat{
+		MOTD:       "A Minecraft Server",
+		GameType:   "SMP",
+		GameID:     "MINECRAFT",
+		Version:    "1.16.5",
+		Map:        "world",
+		NumPlayers: 2,
+		MaxPlayers: 20,
+		HostPort:   25565,
+		HostIP:     "127.0.0.1",
+		Plugins:    []string{},
+		Players:    []string{"Steve", "Alex"},
+	}
+	stat.KeyValues = nil
+	if !reflect.DeepEqual(stat, want) {
+		t.Errorf("FullStat() = %+v, want %+v", stat, want)
+	}
+}
+
+func TestParseFullStatPaper(t *testing.T) {
+	stat, err := parseFullStat(fullStatPayload([][2]string{
+		{"hostname", "Paper"},
+		{"plugins", "Paper on 1.20.1-R0.1-SNAPSHOT: WorldEdit 7.2.15; LuckPerms 5.4.102"},
+		{"numplayers", "0"},
+	}, nil))
+	if err != nil {
+		t.Fatalf("parseFullStat() error = %v", err)
+	}
+
+	if stat.Software != "Paper on 1.20.1-R0.1-SNAPSHOT" {
+		t.Errorf("Software = %q", stat.Software)
+	}
+	if want := []string{"WorldEdit 7.2.15", "LuckPerms 5.4.102"}; !reflect.DeepEqual(stat.Plugins, want) {
+		t.Errorf("Plugins = %q, want %q", stat.Plugins, want)
+	}
+	if len(stat.Players) != 0 {
+		t.Errorf("Players = %q, want none", stat.Players)
+	}
+	if stat.KeyValues["hostname"] != "Paper" {
+		t.Errorf("KeyValues = %v", stat.KeyValues)
+	}
+}
+
+func TestParseFullStatInvalid(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":           nil,
+		"missing players": append(append([]byte{}, keyValuesStart...), "hostname\x00motd\x00\x00"...),
+	} {
+		if _, err := parseFullStat(data); err == nil {
+			t.Errorf("parseFullStat(%s) error = nil, want an error", name)
+		}
+	}
+}
+
+func TestParsePlugins(t *testing.T) {
+	tests := []struct {
+		in       string
+		software string
+		plugins  []string
+	}{
+		{"", "", []string{}},
+		{"Paper on 1.20.1-R0.1-SNAPSHOT", "Paper on 1.20.1-R0.1-SNAPSHOT", []string{}},
+		{"CraftBukkit on Bukkit 1.16.5: Essentials 2.18.2", "CraftBukkit on Bukkit 1.16.5", []string{"Essentials 2.18.2"}},
+		{"Paper on 1.20.1: WorldEdit 7.2.15; LuckPerms 5.4.102;", "Paper on 1.20.1", []string{"WorldEdit 7.2.15", "LuckPerms 5.4.102"}},
+	}
+
+	for _, tt := range tests {
+		software, plugins := parsePlugins(tt.in)
+		if software != tt.software || !reflect.DeepEqual(plugins, tt.plugins) {
+			t.Errorf("parsePlugins(%q) = %q, %q, want %q, %q", tt.in, software, plugins, tt.software, tt.plugins)
+		}
+	}
+}
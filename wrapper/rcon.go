@@ -0,0 +1,121 @@
+package wrapper
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/rcon"
+	"github.com/sirupsen/logrus"
+)
+
+// rconConfig config of the RCON transport
+type rconConfig struct {
+	Enabled  bool
+	Host     string
+	Port     int
+	Password string
+	Timeout  time.Duration
+}
+
+// rconTransport sends commands to the Minecraft Server over RCON
+// the connection is established lazily and reset on errors
+type rconTransport struct {
+	mu      sync.Mutex
+	client  *rcon.Client
+	wrapper *Wrapper
+}
+
+// dial connects to the RCON server configured in the server.properties
+// mc.rcon.port and mc.rcon.password take precedence
+func (t *rconTransport) dial() (*rcon.Client, error) {
+	w := t.wrapper
+	port := w.conf().Rcon.Port
+	password := w.conf().Rcon.Password
+
+	if port == 0 || password == "" {
+		props, err := w.serverProperties()
+		if err != nil {
+			return nil, err
+		}
+
+		if props["enable-rcon"] != "true" {
+			return nil, fmt.Errorf("rcon is not enabled in server.properties")
+		}
+		if port == 0 {
+			port, _ = strconv.Atoi(props["rcon.port"])
+		}
+		if password == "" {
+			password = props["rcon.password"]
+		}
+	}
+
+	if port == 0 {
+		port = 25575
+	}
+	if password == "" {
+		return nil, fmt.Errorf("no rcon password configured")
+	}
+
+	return rcon.Dial(net.JoinHostPort(w.conf().Rcon.Host, fmt.Sprint(port)), password, w.conf().Rcon.Timeout)
+}
+
+// command sends the command and returns the response
+// sent reports if the command may have reached the server,
+// it's false only if connecting or authenticating failed
+func (t *rconTransport) command(cmd string) (response string, sent bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client == nil {
+		client, err := t.dial()
+		if err != nil {
+			return "", false, err
+		}
+		t.client = client
+	}
+
+	response, err = t.client.Command(cmd)
+	if err != nil {
+		t.client.Close()
+		t.client = nil
+		return "", true, err
+	}
+
+	return response, true, nil
+}
+
+// close closes the connection
+func (t *rconTransport) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+}
+
+// sendServerCommand sends a command to the Minecraft Server
+// uses RCON if enabled and falls back to the console if RCON isn't reachable
+// a command that may have reached the server isn't sent again
+func (w *Wrapper) sendServerCommand(cmd string) (string, error) {
+	if w.conf().Rcon.Enabled {
+		response, sent, err := w.rcon.command(cmd)
+		if err == nil {
+			return response, nil
+		}
+
+		if sent || w.console == nil {
+			return "", err
+		}
+		logrus.Warnf("rcon failed, falling back to console: %v", err)
+	}
+
+	if w.console == nil {
+		return "", fmt.Errorf("server not running")
+	}
+	return "", w.console.WriteCmd(cmd)
+}
@@ -0,0 +1,184 @@
+// Package rcon implements a client for the Source RCON protocol used by the Minecraft Server
+package rcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// packet types
+const (
+	typeResponse int32 = 0
+	typeCommand  int32 = 2
+	typeAuthResp int32 = 2
+	typeAuth     int32 = 3
+)
+
+const (
+	// maximum size of a packet body the server accepts
+	maxBodySize = 1446
+	// maximum size of a received packet
+	maxPacketSize = 4096 + 10
+)
+
+// Client a RCON client
+type Client struct {
+	mu      sync.Mutex
+	conn    net.Conn
+	id      int32
+	timeout time.Duration
+}
+
+// packet a RCON packet
+type packet struct {
+	id   int32
+	typ  int32
+	body string
+}
+
+// Dial connects to the RCON server and authenticates with the password
+func Dial(addr, password string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn:    conn,
+		timeout: timeout,
+	}
+
+	if err := c.auth(password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// auth authenticates the connection
+func (c *Client) auth(password string) error {
+	id := c.nextID()
+	if err := c.write(packet{id: id, typ: typeAuth, body: password}); err != nil {
+		return err
+	}
+
+	for {
+		p, err := c.read()
+		if err != nil {
+			return err
+		}
+
+		if p.typ != typeAuthResp {
+			continue
+		}
+
+		if p.id == -1 {
+			return fmt.Errorf("rcon authentication failed")
+		}
+		if p.id != id {
+			return fmt.Errorf("rcon authentication returned unexpected id %d", p.id)
+		}
+		return nil
+	}
+}
+
+// Command executes the command and returns the response
+func (c *Client) Command(cmd string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(cmd) > maxBodySize {
+		return "", fmt.Errorf("command too long")
+	}
+
+	id := c.nextID()
+	if err := c.write(packet{id: id, typ: typeCommand, body: cmd}); err != nil {
+		return "", err
+	}
+
+	// the response may be split into multiple packets,
+	// so an invalid packet is sent whose answer marks the end
+	end := c.nextID()
+	if err := c.write(packet{id: end, typ: typeResponse}); err != nil {
+		return "", err
+	}
+
+	var response strings.Builder
+	for {
+		p, err := c.read()
+		if err != nil {
+			return "", err
+		}
+
+		switch p.id {
+		case id:
+			response.WriteString(p.body)
+		case end:
+			return response.String(), nil
+		}
+	}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// nextID returns the next request id
+func (c *Client) nextID() int32 {
+	c.id++
+	return c.id
+}
+
+// write writes a packet to the connection
+func (c *Client) write(p packet) error {
+	var buf bytes.Buffer
+	length := int32(4 + 4 + len(p.body) + 2)
+
+	//nolint:errcheck
+	binary.Write(&buf, binary.LittleEndian, length)
+	//nolint:errcheck
+	binary.Write(&buf, binary.LittleEndian, p.id)
+	//nolint:errcheck
+	binary.Write(&buf, binary.LittleEndian, p.typ)
+	buf.WriteString(p.body)
+	buf.Write([]byte{0, 0})
+
+	//nolint:errcheck
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err := c.conn.Write(buf.Bytes())
+	return err
+}
+
+// read reads a packet from the connection
+func (c *Client) read() (packet, error) {
+	var p packet
+
+	//nolint:errcheck
+	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+
+	var length int32
+	if err := binary.Read(c.conn, binary.LittleEndian, &length); err != nil {
+		return p, err
+	}
+	if length < 10 || length > maxPacketSize {
+		return p, fmt.Errorf("invalid rcon packet length %d", length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(c.conn, data); err != nil {
+		return p, err
+	}
+
+	p.id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	p.typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+	p.body = string(bytes.TrimRight(data[8:], "\x00"))
+	return p, nil
+}
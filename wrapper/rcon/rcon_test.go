@@ -0,0 +1,155 @@
+package rcon
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeServer serves a single connection with the handler
+func fakeServer(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+
+	return l.Addr().String()
+}
+
+// readPacket reads a packet sent by the client
+func readPacket(conn net.Conn) (packet, error) {
+	var length int32
+	if err := binary.Read(conn, binary.LittleEndian, &length); err != nil {
+		return packet{}, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(conn, data); err != nil {
+		return packet{}, err
+	}
+	return packet{
+		id:   int32(binary.LittleEndian.Uint32(data[0:4])),
+		typ:  int32(binary.LittleEndian.Uint32(data[4:8])),
+		body: strings.TrimRight(string(data[8:]), "\x00"),
+	}, nil
+}
+
+// writePacket writes a packet to the client
+func writePacket(conn net.Conn, p packet) {
+	c := &Client{conn: conn, timeout: time.Second}
+	c.write(p) //nolint:errcheck
+}
+
+// authenticate answers the auth packet, with the id or -1 if the password is wrong
+func authenticate(conn net.Conn, password string) bool {
+	p, err := readPacket(conn)
+	if err != nil || p.typ != typeAuth {
+		return false
+	}
+
+	// the server sends an empty response before the auth response
+	writePacket(conn, packet{id: p.id, typ: typeResponse})
+	if p.body != password {
+		writePacket(conn, packet{id: -1, typ: typeAuthResp})
+		return false
+	}
+	writePacket(conn, packet{id: p.id, typ: typeAuthResp})
+	return true
+}
+
+func TestAuth(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) { authenticate(conn, "secret") })
+
+	c, err := Dial(addr, "secret", time.Second)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	c.Close()
+}
+
+func TestAuthFailed(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) { authenticate(conn, "secret") })
+
+	if _, err := Dial(addr, "wrong", time.Second); err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("Dial() error = %v, want authentication failed", err)
+	}
+}
+
+func TestAuthUnexpectedID(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) {
+		p, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+		writePacket(conn, packet{id: p.id + 42, typ: typeAuthResp})
+	})
+
+	if _, err := Dial(addr, "secret", time.Second); err == nil || !strings.Contains(err.Error(), "unexpected id") {
+		t.Fatalf("Dial() error = %v, want unexpected id", err)
+	}
+}
+
+func TestCommandMultiPacket(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) {
+		if !authenticate(conn, "secret") {
+			return
+		}
+
+		cmd, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+		end, err := readPacket(conn)
+		if err != nil {
+			return
+		}
+
+		// a stale packet of another request is skipped
+		writePacket(conn, packet{id: cmd.id - 1, typ: typeResponse, body: "stale"})
+		writePacket(conn, packet{id: cmd.id, typ: typeResponse, body: "There are 2 of a max "})
+		writePacket(conn, packet{id: cmd.id, typ: typeResponse, body: "of 20 players online: " + cmd.body})
+		writePacket(conn, packet{id: end.id, typ: typeResponse, body: "Unknown request 0"})
+	})
+
+	c, err := Dial(addr, "secret", time.Second)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Close()
+
+	got, err := c.Command("list")
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	if want := "There are 2 of a max of 20 players online: list"; got != want {
+		t.Errorf("Command() = %q, want %q", got, want)
+	}
+}
+
+func TestCommandTooLong(t *testing.T) {
+	addr := fakeServer(t, func(conn net.Conn) { authenticate(conn, "secret") })
+
+	c, err := Dial(addr, "secret", time.Second)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Command(strings.Repeat("a", maxBodySize+1)); err == nil {
+		t.Error("Command() error = nil, want command too long")
+	}
+}
@@ -0,0 +1,202 @@
+package wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/momper14/msw/cron"
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/sirupsen/logrus"
+)
+
+// loop a background loop, restarted when its config changes
+type loop struct {
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+	run  func(stop <-chan struct{})
+}
+
+// restart stops the running loop and starts it again
+// waits until the running loop returned, so they never run in parallel
+func (l *loop) restart() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.stop != nil {
+		close(l.stop)
+		<-l.done
+	}
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	l.stop, l.done = stop, done
+	go func() {
+		defer close(done)
+		l.run(stop)
+	}()
+}
+
+// conf returns the config in use
+func (w *Wrapper) conf() *instanceConfig {
+	w.configMu.RLock()
+	defer w.configMu.RUnlock()
+
+	return w.config
+}
+
+// pendingSettings returns the settings of next which differ from active
+// and are only applied when the Minecraft Server starts
+func pendingSettings(active, next *instanceConfig) []string {
+	pending := make([]string, 0)
+	if active.Heapsize != next.Heapsize {
+		pending = append(pending, "heapsize")
+	}
+	if active.Jar != next.Jar {
+		pending = append(pending, "jar")
+	}
+	if active.Workingdir != next.Workingdir {
+		pending = append(pending, "workingdir")
+	}
+	if active.Eula != next.Eula {
+		pending = append(pending, "eula")
+	}
+	if !reflect.DeepEqual(active.JvmArgs, next.JvmArgs) {
+		pending = append(pending, "jvmArgs")
+	}
+	if !reflect.DeepEqual(active.ServerArgs, next.ServerArgs) {
+		pending = append(pending, "serverArgs")
+	}
+	if !reflect.DeepEqual(active.Launch, next.Launch) {
+		pending = append(pending, "launch")
+	}
+	return pending
+}
+
+// Pending returns the changed settings pending until the next start of the Minecraft Server
+// staged changes of the plugins and mods are pending as jars
+func (w *Wrapper) Pending() []string {
+	w.configMu.RLock()
+	pending := pendingSettings(w.config, w.next)
+	w.configMu.RUnlock()
+
+	if w.hasStagedJars() {
+		pending = append(pending, "jars")
+	}
+	return pending
+}
+
+// publishPending publishes the pending settings
+func (w *Wrapper) publishPending(pending []string) {
+	w.publish(&model.Message{
+		Type:    model.TypePending,
+		Payload: pending,
+	})
+}
+
+// applyConfig applies the reloaded config
+// the settings used to start the Minecraft Server are kept until the next start,
+// all other settings are applied live
+func (w *Wrapper) applyConfig(next *instanceConfig) {
+	w.configMu.Lock()
+	prev := w.config
+	live := *next
+	live.Heapsize = prev.Heapsize
+	live.Jar = prev.Jar
+	live.Workingdir = prev.Workingdir
+	live.Eula = prev.Eula
+	live.JvmArgs = prev.JvmArgs
+	live.ServerArgs = prev.ServerArgs
+	live.Launch = prev.Launch
+	w.config = &live
+	w.next = next
+	pending := pendingSettings(&live, next)
+	w.configMu.Unlock()
+
+	if prev.Rcon != live.Rcon {
+		w.rcon.close()
+	}
+	if prev.Backup.Schedule != live.Backup.Schedule {
+		w.backupLoop.restart()
+	}
+	if prev.Metrics != live.Metrics {
+		w.ticksLoop.restart()
+	}
+	if prev.Stats != live.Stats {
+		w.sampler.resize(statsSize(live.Stats))
+		w.statsLoop.restart()
+	}
+	if prev.Slp != live.Slp {
+		w.statusLoop.restart()
+	}
+	if prev.Query != live.Query {
+		w.queryLoop.restart()
+	}
+	w.reloadSchedule(prev.Schedule, live.Schedule)
+
+	if len(pending) > 0 {
+		logrus.Infof("settings %v of %s are pending until the next restart", pending, w.Name())
+	}
+	w.publishPending(pending)
+}
+
+// applyPending applies the pending settings before the Minecraft Server starts
+func (w *Wrapper) applyPending() {
+	w.configMu.Lock()
+	pending := pendingSettings(w.config, w.next)
+	w.config = w.next
+	w.configMu.Unlock()
+
+	if len(pending) > 0 {
+		w.publishPending(make([]string, 0))
+	}
+}
+
+// validateReload validates the parts of the config which are only checked when used
+// so an invalid schedule doesn't replace a working one
+func validateReload(c *instanceConfig) error {
+	if c.Backup.Schedule != "" {
+		if _, err := cron.Parse(c.Backup.Schedule); err != nil {
+			return fmt.Errorf("instance %s: invalid backup schedule: %v", c.Name, err)
+		}
+	}
+	if err := validateSchedule(c.Schedule); err != nil {
+		return fmt.Errorf("instance %s: %v", c.Name, err)
+	}
+	return nil
+}
+
+// reload loads the configs of the instances from the reread config
+// instances are matched by name, adding or removing instances requires restarting the MSW
+func (c *Controller) reload() (func(), error) {
+	configs, err := loadConfigs()
+	if err != nil {
+		return nil, err
+	}
+	for _, conf := range configs {
+		if err := validateReload(conf); err != nil {
+			return nil, err
+		}
+	}
+
+	return func() {
+		byName := make(map[string]*instanceConfig)
+		for _, conf := range configs {
+			byName[conf.Name] = conf
+		}
+
+		for _, w := range c.wrappers {
+			conf, ok := byName[w.Name()]
+			if !ok {
+				logrus.Warnf("instance %s was removed, removing instances requires restarting the MSW", w.Name())
+				continue
+			}
+			delete(byName, w.Name())
+			w.applyConfig(conf)
+		}
+
+		for name := range byName {
+			logrus.Warnf("instance %s was added, adding instances requires restarting the MSW", name)
+		}
+	}, nil
+}
@@ -0,0 +1,217 @@
+package wrapper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/sirupsen/logrus"
+)
+
+// restartConfig config of the restart policy
+type restartConfig struct {
+	Policy     string
+	Maxretries int
+	Backoff    time.Duration
+	Maxbackoff time.Duration
+	Crashloop  struct {
+		Count  int
+		Window time.Duration
+	}
+}
+
+// restarter keeps track of how the Minecraft Server exited
+// and decides if it gets restarted
+type restarter struct {
+	mu        sync.Mutex
+	stopping  bool
+	requested bool
+	retries   int
+	crashes   []time.Time
+	timer     *time.Timer
+}
+
+// reset resets the tracking of the current run
+func (r *restarter) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopping = false
+	r.requested = false
+}
+
+// run returns how the current run ended, stopping and requested
+// must be read before the Offline transition, a waiting restart resets them
+func (r *restarter) run() (stopping, requested bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stopping, r.requested
+}
+
+// cancel cancels a pending restart
+// returns if a restart was pending
+func (r *restarter) cancel() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.timer == nil {
+		return false
+	}
+
+	pending := r.timer.Stop()
+	r.timer = nil
+	return pending
+}
+
+// backoff calculates the delay for the given attempt
+func (w *Wrapper) backoff(attempt int) time.Duration {
+	delay := w.conf().Restart.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if w.conf().Restart.Maxbackoff > 0 && delay >= w.conf().Restart.Maxbackoff {
+			return w.conf().Restart.Maxbackoff
+		}
+	}
+	return delay
+}
+
+// exited handles the exit of the Minecraft Server process
+// stopping and requested are the state of the run taken before going Offline
+func (w *Wrapper) exited(exitErr error, stopping, requested bool) {
+	r := w.restarter
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	crashed := exitErr != nil || !stopping
+	status := "exited cleanly"
+	if crashed {
+		status = "crashed"
+		if exitErr != nil {
+			status = fmt.Sprintf("crashed (%s)", exitErr)
+		}
+		w.metrics.crashes.Inc()
+	}
+
+	policy := RestartPolicyFor(w.conf().Restart.Policy)
+	switch {
+	case requested:
+		w.publishRestart(fmt.Sprintf("server %s after stop request, not restarting", status))
+		return
+	case policy == RestartAlways:
+	case policy == RestartOnFailure && crashed:
+	default:
+		w.publishRestart(fmt.Sprintf("server %s, not restarting because of restart policy %s", status, policy))
+		return
+	}
+
+	now := time.Now()
+	if crashed && w.conf().Restart.Crashloop.Count > 0 {
+		var recent []time.Time
+		for _, t := range r.crashes {
+			if now.Sub(t) < w.conf().Restart.Crashloop.Window {
+				recent = append(recent, t)
+			}
+		}
+		r.crashes = append(recent, now)
+
+		if len(r.crashes) >= w.conf().Restart.Crashloop.Count {
+			w.publishRestart(fmt.Sprintf("server %s %d times within %s, crash loop detected, giving up",
+				status, len(r.crashes), w.conf().Restart.Crashloop.Window))
+			return
+		}
+	}
+
+	w.scheduleRestart("server " + status)
+}
+
+// scheduleRestart schedules the next restart attempt with backoff
+// a failed start counts as attempt and schedules the next one
+// r.mu must be held
+func (w *Wrapper) scheduleRestart(reason string) {
+	r := w.restarter
+
+	r.retries++
+	if w.conf().Restart.Maxretries > 0 && r.retries > w.conf().Restart.Maxretries {
+		w.publishRestart(fmt.Sprintf("%s, giving up after %d restart attempts", reason, w.conf().Restart.Maxretries))
+		return
+	}
+
+	delay := w.backoff(r.retries)
+	w.publishRestart(fmt.Sprintf("%s, restarting in %s (attempt %d)", reason, delay, r.retries))
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		r.mu.Lock()
+		if r.timer != timer {
+			// cancelled in the meantime
+			r.mu.Unlock()
+			return
+		}
+		r.timer = nil
+		r.mu.Unlock()
+
+		w.metrics.restarts.WithLabelValues("policy").Inc()
+		err := w.start()
+		if err == nil {
+			return
+		}
+		if errors.Is(err, ErrServerBusy) {
+			// started by someone else in the meantime
+			logrus.Warn(err)
+			return
+		}
+
+		logrus.Error(err)
+		w.publishLog(err.Error())
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		w.scheduleRestart(fmt.Sprintf("server failed to start (%v)", err))
+	})
+	r.timer = timer
+}
+
+// online resets the restart attempts after a successful start
+func (r *restarter) online() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retries = 0
+}
+
+// stopped marks that the Minecraft Server went through stopping
+func (r *restarter) stopped() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopping = true
+}
+
+// request marks that the stop was requested by the wrapper
+func (r *restarter) request() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requested = true
+}
+
+// clear clears the restart attempts and crash history
+func (r *restarter) clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retries = 0
+	r.crashes = nil
+}
+
+// publishRestart publishes a restart decision
+func (w *Wrapper) publishRestart(decision string) {
+	logrus.Info(decision)
+	w.publish(&model.Message{
+		Type:    model.TypeRestart,
+		Payload: decision,
+	})
+}
@@ -0,0 +1,51 @@
+package wrapper
+
+import "fmt"
+
+// RestartPolicy enum of restart policies
+type RestartPolicy int
+
+// possible restart policies
+const (
+	RestartNever RestartPolicy = iota + 1
+	RestartOnFailure
+	RestartAlways
+)
+
+var restartPolicyMap = map[RestartPolicy]string{
+	RestartNever:     "never",
+	RestartOnFailure: "on-failure",
+	RestartAlways:    "always",
+}
+
+func (p RestartPolicy) String() string {
+	if val, ok := restartPolicyMap[p]; ok {
+		return val
+	}
+
+	return "unknown"
+}
+
+// RestartPolicyFor returns RestartPolicy for the given string
+// ignores errors
+func RestartPolicyFor(s string) RestartPolicy {
+	policy, _ := RestartPolicyForE(s)
+	return policy
+}
+
+// RestartPolicyForE returns RestartPolicy for the given string
+func RestartPolicyForE(s string) (RestartPolicy, error) {
+	for k, v := range restartPolicyMap {
+		if v == s {
+			return k, nil
+		}
+	}
+
+	return RestartPolicy(0), fmt.Errorf("no known restart policy for %s", s)
+}
+
+// Validate validates that the value is a valide enum value
+func (p RestartPolicy) Validate() (ok bool) {
+	_, ok = restartPolicyMap[p]
+	return
+}
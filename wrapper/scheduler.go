@@ -0,0 +1,232 @@
+package wrapper
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/cron"
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/sirupsen/logrus"
+)
+
+// scheduleUser the user scheduled commands are issued by
+const scheduleUser = "scheduler"
+
+// Job a job running commands on a cron schedule
+type Job struct {
+	Name     string           `json:"name"`
+	Cron     string           `json:"cron"`
+	Always   bool             `json:"always"`
+	Commands []*model.Command `json:"commands"`
+	Next     time.Time        `json:"next"`
+}
+
+// jobConfig config of a job
+// the target is a string, so it can't be decoded into a command directly
+type jobConfig struct {
+	Cron     string
+	Always   bool
+	Commands []struct {
+		Target  string
+		Payload string
+	}
+}
+
+// scheduledJob a job with its parsed schedule
+type scheduledJob struct {
+	job      Job
+	schedule *cron.Schedule
+	cancel   chan struct{}
+}
+
+// scheduler runs the jobs
+type scheduler struct {
+	mu   sync.Mutex
+	jobs map[string]*scheduledJob
+}
+
+// newJob creates the job of its config
+func newJob(name string, c jobConfig) (Job, error) {
+	job := Job{Name: name, Cron: c.Cron, Always: c.Always}
+	for _, cmd := range c.Commands {
+		target, err := model.TargetForE(cmd.Target)
+		if err != nil {
+			return Job{}, fmt.Errorf("job %s: %v", name, err)
+		}
+		job.Commands = append(job.Commands, &model.Command{Target: target, Payload: cmd.Payload})
+	}
+	return job, nil
+}
+
+// loadJobs loads the jobs configured under schedule
+func loadJobs(schedule map[string]jobConfig) ([]Job, error) {
+	jobs := make([]Job, 0, len(schedule))
+	for name, c := range schedule {
+		job, err := newJob(name, c)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// validateSchedule validates the targets and cron expressions of the jobs
+func validateSchedule(schedule map[string]jobConfig) error {
+	jobs, err := loadJobs(schedule)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if _, err := cron.Parse(job.Cron); err != nil {
+			return fmt.Errorf("job %s: %v", job.Name, err)
+		}
+	}
+	return nil
+}
+
+// runScheduler schedules the configured jobs
+func (w *Wrapper) runScheduler() {
+	jobs, err := loadJobs(w.conf().Schedule)
+	if err != nil {
+		logrus.Errorf("invalid schedule of %s: %v", w.Name(), err)
+		return
+	}
+
+	for _, job := range jobs {
+		if err := w.AddSchedule(job); err != nil {
+			logrus.Errorf("invalid schedule of %s: %v", w.Name(), err)
+		}
+	}
+}
+
+// reloadSchedule applies the changes of the configured jobs
+// jobs added through the API are kept unless the config has a job of the same name
+func (w *Wrapper) reloadSchedule(prev, next map[string]jobConfig) {
+	for name := range prev {
+		if _, ok := next[name]; !ok {
+			if err := w.RemoveSchedule(name); err != nil {
+				logrus.Debug(err)
+			}
+		}
+	}
+
+	for name, c := range next {
+		if old, ok := prev[name]; ok && reflect.DeepEqual(old, c) {
+			continue
+		}
+
+		job, err := newJob(name, c)
+		if err == nil {
+			err = w.AddSchedule(job)
+		}
+		if err != nil {
+			logrus.Errorf("invalid schedule of %s: %v", w.Name(), err)
+		}
+	}
+}
+
+// Schedules returns the scheduled jobs sorted by name
+func (w *Wrapper) Schedules() []Job {
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, sj := range s.jobs {
+		job := sj.job
+		job.Next = sj.schedule.Next(time.Now())
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// AddSchedule adds the job, replacing a job with the same name
+func (w *Wrapper) AddSchedule(job Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("job without name")
+	}
+	if len(job.Commands) == 0 {
+		return fmt.Errorf("job %s without commands", job.Name)
+	}
+	for _, cmd := range job.Commands {
+		if !cmd.Target.Validate() {
+			return fmt.Errorf("job %s has a command with invalid target", job.Name)
+		}
+	}
+
+	schedule, err := cron.Parse(job.Cron)
+	if err != nil {
+		return fmt.Errorf("job %s: %v", job.Name, err)
+	}
+
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.jobs[job.Name]; ok {
+		close(old.cancel)
+	}
+
+	sj := &scheduledJob{
+		job:      job,
+		schedule: schedule,
+		cancel:   make(chan struct{}),
+	}
+	s.jobs[job.Name] = sj
+
+	go w.runJob(sj)
+	return nil
+}
+
+// RemoveSchedule removes the job
+func (w *Wrapper) RemoveSchedule(name string) error {
+	s := w.scheduler
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sj, ok := s.jobs[name]
+	if !ok {
+		return fmt.Errorf("no job named %s", name)
+	}
+
+	close(sj.cancel)
+	delete(s.jobs, name)
+	return nil
+}
+
+// runJob runs the job on its schedule until it's cancelled
+func (w *Wrapper) runJob(sj *scheduledJob) {
+	for {
+		next := sj.schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-time.After(time.Until(next)):
+		case <-sj.cancel:
+			return
+		}
+
+		if !sj.job.Always && w.CurrentState() != ServerOnline {
+			logrus.Debugf("skipping job %s, server is %s", sj.job.Name, w.CurrentState())
+			continue
+		}
+
+		logrus.Infof("running job %s", sj.job.Name)
+		for _, cmd := range sj.job.Commands {
+			w.commands <- &model.Command{
+				Target:  cmd.Target,
+				Payload: cmd.Payload,
+				User:    scheduleUser,
+			}
+		}
+	}
+}
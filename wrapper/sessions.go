@@ -0,0 +1,209 @@
+package wrapper
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/logsearch"
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/momper14/msw/wrapper/sessions"
+	"github.com/sirupsen/logrus"
+)
+
+// sessionsConfig config of the player sessions
+type sessionsConfig struct {
+	File string
+}
+
+// sessionRecorder records the sessions of the players
+// sessions are stored when the player leaves or the server goes offline
+type sessionRecorder struct {
+	mu    sync.Mutex
+	path  string
+	store *sessions.Store
+	open  map[string]sessions.Session
+}
+
+// newSessionRecorder initialises a sessionRecorder without open sessions
+func newSessionRecorder() *sessionRecorder {
+	return &sessionRecorder{open: make(map[string]sessions.Session)}
+}
+
+// get returns the store of the file, opened on first use
+// a changed file is opened on the next access
+func (r *sessionRecorder) get(path string) (*sessions.Store, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store != nil && r.path == path {
+		return r.store, nil
+	}
+
+	store, err := sessions.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if r.store != nil {
+		r.store.Close()
+	}
+	r.path, r.store = path, store
+	return store, nil
+}
+
+// store returns the sessions store of the instance
+func (w *Wrapper) store() (*sessions.Store, error) {
+	return w.recorder.get(w.conf().Sessions.File)
+}
+
+// addSession stores the session and logs failures
+func (w *Wrapper) addSession(s sessions.Session) {
+	store, err := w.store()
+	if err == nil {
+		_, err = store.Add(s)
+	}
+	if err != nil {
+		logrus.Errorf("failed to record the session of %s: %v", s.Name, err)
+	}
+}
+
+// recordSession opens or closes the session of the player by the player event
+func (w *Wrapper) recordSession(msg *model.Message) {
+	r := w.recorder
+
+	switch e := msg.Payload.(type) {
+	case model.PlayerJoin:
+		r.mu.Lock()
+		r.open[e.Name] = sessions.Session{UUID: e.UUID, Name: e.Name, IP: e.IP, Join: time.Now()}
+		r.mu.Unlock()
+	case model.PlayerLeave:
+		r.mu.Lock()
+		s, ok := r.open[e.Name]
+		delete(r.open, e.Name)
+		r.mu.Unlock()
+
+		if ok {
+			s.Leave = time.Now()
+			w.addSession(s)
+		}
+	}
+}
+
+// closeSessions closes the sessions of all players, the server went offline
+func (w *Wrapper) closeSessions() {
+	r := w.recorder
+	r.mu.Lock()
+	open := r.open
+	r.open = make(map[string]sessions.Session)
+	r.mu.Unlock()
+
+	for _, s := range open {
+		s.Leave = time.Now()
+		w.addSession(s)
+	}
+}
+
+// PlayerSessions returns the aggregated and all sessions of the player by uuid or name
+func (w *Wrapper) PlayerSessions(player string) (sessions.PlayerStats, []sessions.Session, error) {
+	store, err := w.store()
+	if err != nil {
+		return sessions.PlayerStats{}, nil, err
+	}
+
+	list, err := store.Sessions(player)
+	if err != nil {
+		return sessions.PlayerStats{}, nil, err
+	}
+	return sessions.Aggregate(list), list, nil
+}
+
+// SessionStats returns the aggregated sessions of all players
+// and the peak of concurrent players of each day
+func (w *Wrapper) SessionStats() ([]sessions.PlayerStats, []sessions.DailyPeak, error) {
+	store, err := w.store()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	players, err := store.Players()
+	if err != nil {
+		return nil, nil, err
+	}
+	peaks, err := store.Peaks()
+	if err != nil {
+		return nil, nil, err
+	}
+	return players, peaks, nil
+}
+
+// Backfill records the sessions of the rotated logs of the Minecraft Server
+// sessions which were already recorded are skipped
+// returns the number of added sessions
+func (w *Wrapper) Backfill() (int, error) {
+	store, err := w.store()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		source = logsearch.NewSource(filepath.Join(w.Workingdir(), "logs"), "latest.log", logsearch.ServerFormat())
+		parser = newEventParser()
+		added  = 0
+	)
+
+	err = source.Rotated(func(entries []logsearch.Entry) error {
+		open := make(map[string]sessions.Session)
+		closeSession := func(name string, t time.Time) error {
+			s := open[name]
+			delete(open, name)
+			s.Leave = t
+			ok, err := store.Add(s)
+			if ok {
+				added++
+			}
+			return err
+		}
+		closeAll := func(t time.Time) error {
+			for name := range open {
+				if err := closeSession(name, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, e := range entries {
+			ll, err := parseToLogLine(e.Line)
+			if err != nil {
+				continue
+			}
+
+			if msg := parser.parse(ll); msg != nil {
+				switch p := msg.Payload.(type) {
+				case model.PlayerJoin:
+					open[p.Name] = sessions.Session{UUID: p.UUID, Name: p.Name, IP: p.IP, Join: e.Time}
+				case model.PlayerLeave:
+					if _, ok := open[p.Name]; ok {
+						if err := closeSession(p.Name, e.Time); err != nil {
+							return err
+						}
+					}
+				}
+			}
+
+			// a log can contain several runs of the server
+			if ev := ll.toEvent(); ev == StartEvent || ev == StopEvent {
+				if err := closeAll(e.Time); err != nil {
+					return err
+				}
+			}
+		}
+
+		// the server stopped at the end of the log
+		if len(entries) > 0 {
+			return closeAll(entries[len(entries)-1].Time)
+		}
+		return nil
+	})
+	return added, err
+}
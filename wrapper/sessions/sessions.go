@@ -0,0 +1,267 @@
+// Package sessions persists the sessions of the players in a bbolt database
+package sessions
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// overlapTolerance sessions of a player this close are the same session,
+// the times of the log lines only have seconds
+const overlapTolerance = 2 * time.Second
+
+// sessionsBucket bucket with a bucket of sessions for each player
+var sessionsBucket = []byte("sessions")
+
+// ErrUnknownPlayer is returned for a player without sessions
+var ErrUnknownPlayer = errors.New("unknown player")
+
+// errOverlap stops iterating the sessions of a player at an overlapping session
+var errOverlap = errors.New("overlapping session")
+
+// Session a session of a player from joining to leaving the game
+type Session struct {
+	UUID  string    `json:"uuid,omitempty"`
+	Name  string    `json:"name"`
+	IP    string    `json:"ip,omitempty"`
+	Join  time.Time `json:"join"`
+	Leave time.Time `json:"leave"`
+	// Duration the duration in seconds
+	Duration float64 `json:"duration"`
+}
+
+// key returns the key of the player, the name if the uuid isn't known
+func (s Session) key() string {
+	if s.UUID != "" {
+		return s.UUID
+	}
+	return s.Name
+}
+
+// overlaps reports if the sessions overlap
+func (s Session) overlaps(o Session) bool {
+	return !s.Join.After(o.Leave.Add(overlapTolerance)) && !o.Join.After(s.Leave.Add(overlapTolerance))
+}
+
+// PlayerStats the aggregated sessions of a player
+type PlayerStats struct {
+	UUID      string    `json:"uuid,omitempty"`
+	Name      string    `json:"name"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// Playtime the total playtime in seconds
+	Playtime float64 `json:"playtime"`
+	Sessions int     `json:"sessions"`
+}
+
+// DailyPeak the peak of concurrent players of a day
+type DailyPeak struct {
+	Day  string `json:"day"`
+	Peak int    `json:"peak"`
+}
+
+// Store the sessions of the players of an instance
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store, creating the file if it doesn't exist
+func Open(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// timeKey returns the key of a session by its join time, ordered by time
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// Add adds the session unless it overlaps a recorded session of the player
+// returns if the session was added, so adding the same sessions again is a no-op
+func (s *Store) Add(session Session) (bool, error) {
+	if session.Leave.Before(session.Join) {
+		session.Leave = session.Join
+	}
+	session.Duration = session.Leave.Sub(session.Join).Seconds()
+
+	added := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		player, err := tx.Bucket(sessionsBucket).CreateBucketIfNotExists([]byte(session.key()))
+		if err != nil {
+			return err
+		}
+
+		err = player.ForEach(func(_, v []byte) error {
+			var recorded Session
+			if err := json.Unmarshal(v, &recorded); err != nil {
+				return err
+			}
+			if recorded.overlaps(session) {
+				return errOverlap
+			}
+			return nil
+		})
+		if err == errOverlap {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		value, err := json.Marshal(session)
+		if err != nil {
+			return err
+		}
+		added = true
+		return player.Put(timeKey(session.Join), value)
+	})
+	return added, err
+}
+
+// Sessions returns the sessions of the player by uuid or name, oldest first
+func (s *Store) Sessions(player string) ([]Session, error) {
+	sessions := make([]Session, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sessionsBucket).Bucket([]byte(player))
+		if b == nil {
+			return ErrUnknownPlayer
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var session Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return err
+			}
+			sessions = append(sessions, session)
+			return nil
+		})
+	})
+	return sessions, err
+}
+
+// each calls fn with the sessions of each player, oldest first
+func (s *Store) each(fn func(sessions []Session)) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, _ []byte) error {
+			var sessions []Session
+			err := tx.Bucket(sessionsBucket).Bucket(k).ForEach(func(_, v []byte) error {
+				var session Session
+				if err := json.Unmarshal(v, &session); err != nil {
+					return err
+				}
+				sessions = append(sessions, session)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if len(sessions) > 0 {
+				fn(sessions)
+			}
+			return nil
+		})
+	})
+}
+
+// Aggregate returns the aggregated sessions of the player
+func Aggregate(sessions []Session) PlayerStats {
+	var stats PlayerStats
+	for i, session := range sessions {
+		if i == 0 || session.Join.Before(stats.FirstSeen) {
+			stats.FirstSeen = session.Join
+		}
+		if session.Leave.After(stats.LastSeen) {
+			stats.LastSeen = session.Leave
+			stats.UUID = session.UUID
+			stats.Name = session.Name
+		}
+		stats.Playtime += session.Duration
+		stats.Sessions++
+	}
+	return stats
+}
+
+// Players returns the aggregated sessions of all players, last seen first
+func (s *Store) Players() ([]PlayerStats, error) {
+	players := make([]PlayerStats, 0)
+	err := s.each(func(sessions []Session) {
+		players = append(players, Aggregate(sessions))
+	})
+
+	sort.Slice(players, func(i, j int) bool { return players[i].LastSeen.After(players[j].LastSeen) })
+	return players, err
+}
+
+// Peaks returns the peak of concurrent players of each day with sessions, oldest first
+// the players online at the start of a day count for that day
+func (s *Store) Peaks() ([]DailyPeak, error) {
+	type event struct {
+		time  time.Time
+		delta int
+	}
+
+	var events []event
+	err := s.each(func(sessions []Session) {
+		for _, session := range sessions {
+			events = append(events, event{session.Join, 1}, event{session.Leave, -1})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// leaving before joining at the same time, so consecutive sessions don't count twice
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].time.Equal(events[j].time) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].time.Before(events[j].time)
+	})
+
+	peaks := make([]DailyPeak, 0)
+	online := 0
+	for _, e := range events {
+		day := e.time.Local().Format("2006-01-02")
+		if len(peaks) == 0 || peaks[len(peaks)-1].Day != day {
+			peaks = append(peaks, DailyPeak{Day: day, Peak: online})
+		}
+
+		online += e.delta
+		if last := &peaks[len(peaks)-1]; online > last.Peak {
+			last.Peak = online
+		}
+	}
+	return peaks, nil
+}
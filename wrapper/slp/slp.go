@@ -0,0 +1,276 @@
+// Package slp implements a client for the Server List Ping protocol of the Minecraft Server
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// packet ids
+const (
+	idHandshake int32 = 0x00
+	idStatus    int32 = 0x00
+	idPing      int32 = 0x01
+)
+
+const (
+	// protocol version sent in the handshake, -1 asks for the version of the server
+	protocolVersion = -1
+	// next state of the handshake requesting the status
+	stateStatus = 1
+	// maximum size of a received packet, the status contains the favicon
+	maxPacketSize = 1 << 20
+)
+
+// errors of the protocol
+var (
+	ErrInvalidPacket = errors.New("invalid packet")
+	ErrPongMismatch  = errors.New("pong doesn't match the ping")
+)
+
+// formatCodeRegexp formatting codes of legacy descriptions
+var formatCodeRegexp = regexp.MustCompile(`§.`)
+
+// Player a player of the sample of the online players
+type Player struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// Description the MOTD, a plain string or a chat component
+// the text is flattened and stripped of formatting codes
+type Description struct {
+	Text string
+}
+
+// chatComponent a chat component of a description
+// the children are components or plain strings
+type chatComponent struct {
+	Text  string            `json:"text"`
+	Extra []json.RawMessage `json:"extra"`
+}
+
+// UnmarshalJSON unmarshals a plain string or a chat component
+func (d *Description) UnmarshalJSON(data []byte) error {
+	var text string
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	} else {
+		var c chatComponent
+		if err := json.Unmarshal(data, &c); err != nil {
+			return err
+		}
+
+		var b strings.Builder
+		b.WriteString(c.Text)
+		for _, raw := range c.Extra {
+			var extra Description
+			if err := extra.UnmarshalJSON(raw); err != nil {
+				return err
+			}
+			b.WriteString(extra.Text)
+		}
+		text = b.String()
+	}
+
+	d.Text = formatCodeRegexp.ReplaceAllString(text, "")
+	return nil
+}
+
+// Status the status of a Minecraft Server
+type Status struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int      `json:"max"`
+		Online int      `json:"online"`
+		Sample []Player `json:"sample"`
+	} `json:"players"`
+	Description Description `json:"description"`
+	Favicon     string      `json:"favicon"`
+
+	// Latency the round trip time of the ping
+	Latency time.Duration `json:"-"`
+}
+
+// Ping requests the status of the Minecraft Server and measures the latency
+func Ping(addr string, timeout time.Duration) (*Status, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, protocolVersion)
+	writeString(&handshake, host)
+	//nolint:errcheck
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, stateStatus)
+	if err := writePacket(conn, idHandshake, handshake.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writePacket(conn, idStatus, nil); err != nil {
+		return nil, err
+	}
+
+	id, data, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != idStatus {
+		return nil, fmt.Errorf("%w: expected status, got packet %d", ErrInvalidPacket, id)
+	}
+	body, err := readString(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	status := new(Status)
+	if err := json.Unmarshal([]byte(body), status); err != nil {
+		return nil, err
+	}
+
+	payload := time.Now().UnixNano()
+	var ping bytes.Buffer
+	//nolint:errcheck
+	binary.Write(&ping, binary.BigEndian, payload)
+	start := time.Now()
+	if err := writePacket(conn, idPing, ping.Bytes()); err != nil {
+		return nil, err
+	}
+
+	id, data, err = readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != idPing || len(data) != 8 {
+		return nil, fmt.Errorf("%w: expected pong, got packet %d", ErrInvalidPacket, id)
+	}
+	if int64(binary.BigEndian.Uint64(data)) != payload {
+		return nil, ErrPongMismatch
+	}
+	status.Latency = time.Since(start)
+
+	return status, nil
+}
+
+// writePacket writes a packet prefixed with its length
+func writePacket(w io.Writer, id int32, data []byte) error {
+	var body bytes.Buffer
+	writeVarInt(&body, id)
+	body.Write(data)
+
+	var packet bytes.Buffer
+	writeVarInt(&packet, int32(body.Len()))
+	packet.Write(body.Bytes())
+
+	_, err := w.Write(packet.Bytes())
+	return err
+}
+
+// readPacket reads a packet and returns its id and data
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length < 1 || length > maxPacketSize {
+		return 0, nil, fmt.Errorf("%w: length %d", ErrInvalidPacket, length)
+	}
+
+	packet := make([]byte, length)
+	if _, err := io.ReadFull(r, packet); err != nil {
+		return 0, nil, err
+	}
+
+	pr := bytes.NewReader(packet)
+	id, err := readVarInt(pr)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	data := make([]byte, pr.Len())
+	//nolint:errcheck
+	pr.Read(data)
+	return id, data, nil
+}
+
+// writeVarInt writes a VarInt, negative values take 5 bytes
+func writeVarInt(w *bytes.Buffer, value int32) {
+	v := uint32(value)
+	for {
+		if v&^0x7f == 0 {
+			w.WriteByte(byte(v))
+			return
+		}
+		w.WriteByte(byte(v&0x7f | 0x80))
+		v >>= 7
+	}
+}
+
+// readVarInt reads a VarInt of at most 5 bytes
+func readVarInt(r io.ByteReader) (int32, error) {
+	var value uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		value |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(value), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: VarInt too long", ErrInvalidPacket)
+}
+
+// writeString writes a string prefixed with its length
+func writeString(w *bytes.Buffer, s string) {
+	writeVarInt(w, int32(len(s)))
+	w.WriteString(s)
+}
+
+// readString reads a string prefixed with its length
+func readString(r *bytes.Reader) (string, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if length < 0 || int(length) > r.Len() {
+		return "", fmt.Errorf("%w: string length %d", ErrInvalidPacket, length)
+	}
+
+	s := make([]byte, length)
+	//nolint:errcheck
+	r.Read(s)
+	return string(s), nil
+}
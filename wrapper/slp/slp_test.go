@@ -0,0 +1,135 @@
+package slp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeServer answers a single status request with the response
+// pong changes the payload of the pong, length overrides the length of the status packet
+func fakeServer(t *testing.T, response string, pong func(payload []byte) []byte, length int32) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		// handshake and status request
+		for i := 0; i < 2; i++ {
+			if _, _, err := readPacket(r); err != nil {
+				return
+			}
+		}
+
+		if length != 0 {
+			var packet bytes.Buffer
+			writeVarInt(&packet, length)
+			conn.Write(packet.Bytes()) //nolint:errcheck
+			return
+		}
+
+		var status bytes.Buffer
+		writeString(&status, response)
+		if err := writePacket(conn, idStatus, status.Bytes()); err != nil {
+			return
+		}
+
+		_, payload, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		if pong != nil {
+			payload = pong(payload)
+		}
+		writePacket(conn, idPing, payload) //nolint:errcheck
+	}()
+
+	return l.Addr().String()
+}
+
+func TestPingStringDescription(t *testing.T) {
+	addr := fakeServer(t, `{
+		"version": {"name": "1.16.5", "protocol": 754},
+		"players": {"max": 20, "online": 1, "sample": [{"name": "Steve", "id": "069a79f4-44e9-4726-a5be-fca90e38aaf5"}]},
+		"description": "§aA §lMinecraft§r Server"
+	}`, nil, 0)
+
+	status, err := Ping(addr, time.Second)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if status.Description.Text != "A Minecraft Server" {
+		t.Errorf("Description = %q, want %q", status.Description.Text, "A Minecraft Server")
+	}
+	if status.Version.Name != "1.16.5" || status.Version.Protocol != 754 {
+		t.Errorf("Version = %+v", status.Version)
+	}
+	if status.Players.Online != 1 || status.Players.Max != 20 || len(status.Players.Sample) != 1 || status.Players.Sample[0].Name != "Steve" {
+		t.Errorf("Players = %+v", status.Players)
+	}
+}
+
+func TestPingChatDescription(t *testing.T) {
+	addr := fakeServer(t, `{
+		"version": {"name": "Paper 1.16.5", "protocol": 754},
+		"players": {"max": 20, "online": 0},
+		"description": {"text": "Welcome ", "extra": [{"text": "to the ", "color": "gold"}, {"text": "§cLobby", "extra": [{"text": "!"}]}]}
+	}`, nil, 0)
+
+	status, err := Ping(addr, time.Second)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if want := "Welcome to the Lobby!"; status.Description.Text != want {
+		t.Errorf("Description = %q, want %q", status.Description.Text, want)
+	}
+}
+
+func TestPingStringExtra(t *testing.T) {
+	addr := fakeServer(t, `{
+		"version": {"name": "Paper 1.20.4", "protocol": 765},
+		"players": {"max": 20, "online": 0},
+		"description": {"text": "", "extra": ["Lobby ", {"text": "Server", "extra": ["!"]}]}
+	}`, nil, 0)
+
+	status, err := Ping(addr, time.Second)
+	if err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+	if want := "Lobby Server!"; status.Description.Text != want {
+		t.Errorf("Description = %q, want %q", status.Description.Text, want)
+	}
+}
+
+func TestPingPongMismatch(t *testing.T) {
+	addr := fakeServer(t, `{"description": "motd"}`, func(payload []byte) []byte {
+		payload[7]++
+		return payload
+	}, 0)
+
+	if _, err := Ping(addr, time.Second); !errors.Is(err, ErrPongMismatch) {
+		t.Errorf("Ping() error = %v, want %v", err, ErrPongMismatch)
+	}
+}
+
+func TestPingOversizedPacket(t *testing.T) {
+	addr := fakeServer(t, "", nil, maxPacketSize+1)
+
+	if _, err := Ping(addr, time.Second); !errors.Is(err, ErrInvalidPacket) {
+		t.Errorf("Ping() error = %v, want %v", err, ErrInvalidPacket)
+	}
+}
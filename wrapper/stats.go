@@ -0,0 +1,231 @@
+package wrapper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/prometheus/procfs"
+	"github.com/sirupsen/logrus"
+)
+
+// statsConfig config of the resource sampling
+type statsConfig struct {
+	Interval  time.Duration
+	Retention time.Duration
+}
+
+// groupUsage cumulative resource usage of a process group
+type groupUsage struct {
+	processes  int
+	cpu        float64
+	rss        uint64
+	threads    int
+	fds        int
+	readBytes  uint64
+	writeBytes uint64
+}
+
+// sampler keeps the samples of the resource usage in a ring buffer
+type sampler struct {
+	mu      sync.RWMutex
+	samples []model.Stats
+	next    int
+	full    bool
+
+	pgid     int
+	prevTime time.Time
+	prev     groupUsage
+}
+
+// newSampler initialises a sampler keeping size samples
+func newSampler(size int) *sampler {
+	if size < 1 {
+		size = 1
+	}
+	return &sampler{samples: make([]model.Stats, size)}
+}
+
+// resize changes the number of samples kept, keeping the newest
+func (s *sampler) resize(size int) {
+	if size < 1 {
+		size = 1
+	}
+	stats := s.list(time.Time{})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(stats) > size {
+		stats = stats[len(stats)-size:]
+	}
+	s.samples = make([]model.Stats, size)
+	copy(s.samples, stats)
+	s.next = len(stats) % size
+	s.full = len(stats) == size
+}
+
+// statsSize returns the number of samples kept for the retention
+func statsSize(c statsConfig) int {
+	if c.Interval <= 0 {
+		return 1
+	}
+	return int(c.Retention / c.Interval)
+}
+
+// add adds the sample, overwriting the oldest if the buffer is full
+func (s *sampler) add(stats model.Stats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples[s.next] = stats
+	s.next = (s.next + 1) % len(s.samples)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// list returns the samples since the time, oldest first
+func (s *sampler) list(since time.Time) []model.Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ordered []model.Stats
+	if s.full {
+		ordered = append(ordered, s.samples[s.next:]...)
+	}
+	ordered = append(ordered, s.samples[:s.next]...)
+
+	stats := make([]model.Stats, 0, len(ordered))
+	for _, st := range ordered {
+		if st.Time.After(since) {
+			stats = append(stats, st)
+		}
+	}
+	return stats
+}
+
+// readGroupUsage sums the resource usage of all processes of the process group
+// the server is started with Setpgid, so the pgid is the pid of the JVM or its start script
+func readGroupUsage(pgid int) (groupUsage, error) {
+	var u groupUsage
+
+	procs, err := procfs.AllProcs()
+	if err != nil {
+		return u, err
+	}
+
+	for _, p := range procs {
+		stat, err := p.Stat()
+		if err != nil || stat.PGRP != pgid {
+			continue
+		}
+
+		u.processes++
+		u.cpu += stat.CPUTime()
+		u.threads += stat.NumThreads
+
+		if status, err := p.NewStatus(); err == nil {
+			u.rss += status.VmRSS
+		}
+		if fds, err := p.FileDescriptorsLen(); err == nil {
+			u.fds += fds
+		}
+		if io, err := p.IO(); err == nil {
+			u.readBytes += io.ReadBytes
+			u.writeBytes += io.WriteBytes
+		}
+	}
+
+	return u, nil
+}
+
+// rate calculates the rate per second of a counter
+// counters of a process group can shrink when processes exit
+func rate(prev, cur float64, elapsed time.Duration) float64 {
+	if cur < prev || elapsed <= 0 {
+		return 0
+	}
+	return (cur - prev) / elapsed.Seconds()
+}
+
+// sample samples the resource usage of the process group
+// the rates are calculated from the previous sample of the same process group
+func (s *sampler) sample(pgid int) (model.Stats, error) {
+	u, err := readGroupUsage(pgid)
+	if err != nil {
+		return model.Stats{}, err
+	}
+
+	now := time.Now()
+	stats := model.Stats{
+		Time:       now,
+		Processes:  u.processes,
+		RSS:        u.rss,
+		Threads:    u.threads,
+		FDs:        u.fds,
+		ReadBytes:  u.readBytes,
+		WriteBytes: u.writeBytes,
+	}
+
+	if s.pgid == pgid && !s.prevTime.IsZero() {
+		elapsed := now.Sub(s.prevTime)
+		stats.CPU = rate(s.prev.cpu, u.cpu, elapsed) * 100
+		stats.ReadRate = rate(float64(s.prev.readBytes), float64(u.readBytes), elapsed)
+		stats.WriteRate = rate(float64(s.prev.writeBytes), float64(u.writeBytes), elapsed)
+	}
+
+	s.pgid = pgid
+	s.prevTime = now
+	s.prev = u
+	return stats, nil
+}
+
+// sampleStats periodically samples the resource usage of the Minecraft Server
+// and publishes the samples until stopped
+func (w *Wrapper) sampleStats(stop <-chan struct{}) {
+	interval := w.conf().Stats.Interval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		if w.IsOffline() || w.console == nil {
+			continue
+		}
+
+		pid := w.console.Pid()
+		if pid == 0 {
+			continue
+		}
+
+		stats, err := w.sampler.sample(pid)
+		if err != nil {
+			logrus.Debugf("failed to sample stats: %v", err)
+			continue
+		}
+		if stats.Processes == 0 {
+			continue
+		}
+
+		w.sampler.add(stats)
+		w.publish(&model.Message{
+			Type:    model.TypeStats,
+			Payload: stats,
+		})
+	}
+}
+
+// Stats returns the samples of the resource usage since the time, oldest first
+func (w *Wrapper) Stats(since time.Time) []model.Stats {
+	return w.sampler.list(since)
+}
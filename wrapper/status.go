@@ -0,0 +1,176 @@
+package wrapper
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/momper14/msw/wrapper/model"
+	"github.com/momper14/msw/wrapper/slp"
+	"github.com/sirupsen/logrus"
+)
+
+// slpConfig config of the Server List Ping probe
+type slpConfig struct {
+	Enabled  bool
+	Host     string
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// statusProbe keeps the last status queried by Server List Ping
+type statusProbe struct {
+	mu     sync.Mutex
+	status model.Status
+}
+
+// newStatusProbe initialises a statusProbe with an unreachable server
+func newStatusProbe() *statusProbe {
+	return &statusProbe{status: unreachable(time.Time{})}
+}
+
+// unreachable returns the status of an unreachable server checked at the time
+func unreachable(checked time.Time) model.Status {
+	return model.Status{Checked: checked, Sample: make([]model.SamplePlayer, 0)}
+}
+
+// get returns the last status
+func (p *statusProbe) get() model.Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.status
+}
+
+// set stores the status and reports if it changed, ignoring the time of the check
+func (p *statusProbe) set(status model.Status) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prev := p.status
+	p.status = status
+	prev.Checked, status.Checked = time.Time{}, time.Time{}
+	prev.Latency, status.Latency = 0, 0
+	return !reflect.DeepEqual(prev, status)
+}
+
+// slpAddr returns the address of the Minecraft Server
+// mc.slp.host takes precedence over server-ip of the server.properties
+func (w *Wrapper) slpAddr() (string, error) {
+	host := w.conf().Slp.Host
+	port := 25565
+
+	props, err := w.serverProperties()
+	if err != nil {
+		return "", err
+	}
+	if host == "" {
+		host = props["server-ip"]
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	if p, err := strconv.Atoi(props["server-port"]); err == nil && p > 0 {
+		port = p
+	}
+
+	return net.JoinHostPort(host, fmt.Sprint(port)), nil
+}
+
+// ping queries the status of the Minecraft Server
+func (w *Wrapper) ping() model.Status {
+	status := unreachable(time.Now())
+
+	addr, err := w.slpAddr()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	s, err := slp.Ping(addr, w.conf().Slp.Timeout)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Reachable = true
+	status.Latency = float64(s.Latency) / float64(time.Millisecond)
+	status.Version = s.Version.Name
+	status.Protocol = s.Version.Protocol
+	status.MOTD = s.Description.Text
+	status.Online = s.Players.Online
+	status.Max = s.Players.Max
+	for _, p := range s.Players.Sample {
+		status.Sample = append(status.Sample, model.SamplePlayer{Name: p.Name, UUID: p.ID})
+	}
+	return status
+}
+
+// probeStatus periodically pings the Minecraft Server while it is starting or online
+// and publishes changes of the status until stopped
+// a starting server answering the ping is online, even if its log wasn't recognised
+func (w *Wrapper) probeStatus(stop <-chan struct{}) {
+	c := w.conf().Slp
+	if !c.Enabled || c.Interval <= 0 {
+		w.updateStatus(unreachable(time.Time{}))
+		return
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+
+		state := w.CurrentState()
+		if state != ServerStarting && state != ServerOnline {
+			w.updateStatus(unreachable(time.Now()))
+			continue
+		}
+
+		status := w.ping()
+		if !status.Reachable {
+			logrus.Debugf("failed to ping %s: %s", w.Name(), status.Error)
+		}
+		w.updateStatus(status)
+
+		if status.Reachable && w.CurrentState() == ServerStarting {
+			if err := w.updateState(StartedEvent); err != nil {
+				logrus.Debug(err)
+			}
+		}
+	}
+}
+
+// updateStatus stores the status and publishes it if it changed
+func (w *Wrapper) updateStatus(status model.Status) {
+	if !w.probe.set(status) {
+		return
+	}
+
+	w.publish(&model.Message{
+		Type:    model.TypeStatus,
+		Payload: status,
+	})
+}
+
+// Status returns the last status queried by Server List Ping
+func (w *Wrapper) Status() model.Status {
+	return w.probe.get()
+}
+
+// Ready returns if the Minecraft Server is online and answers the Server List Ping
+// if the probe is disabled, being online is enough
+func (w *Wrapper) Ready() bool {
+	if w.CurrentState() != ServerOnline {
+		return false
+	}
+	return !w.conf().Slp.Enabled || w.probe.get().Reachable
+}
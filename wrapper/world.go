@@ -0,0 +1,104 @@
+package wrapper
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/momper14/msw/wrapper/world"
+)
+
+// saveInterval minimum time between saves before reading the player files
+const saveInterval = 10 * time.Second
+
+// world returns the main world of the Minecraft Server by the level-name of the server.properties
+func (w *Wrapper) world() *world.World {
+	name := "world"
+	if props, err := w.serverProperties(); err == nil && props["level-name"] != "" {
+		name = props["level-name"]
+	}
+	return world.New(filepath.Join(w.conf().Workingdir, name))
+}
+
+// saveWorlds saves the worlds if the server is online, so the player files are current
+// waits for running backups, saves while archiving would change the worlds
+func (w *Wrapper) saveWorlds() error {
+	if w.CurrentState() != ServerOnline {
+		return nil
+	}
+
+	w.backupMu.Lock()
+	defer w.backupMu.Unlock()
+
+	if time.Since(w.savedAt) < saveInterval {
+		return nil
+	}
+
+	watcher := w.logWatchers.watch(savedRegexp)
+	defer w.logWatchers.unwatch(watcher)
+
+	if err := w.console.WriteCmd("save-all"); err != nil {
+		return err
+	}
+
+	select {
+	case <-watcher.match:
+		w.savedAt = time.Now()
+		return nil
+	case <-time.After(w.conf().Backup.Timeout):
+		return fmt.Errorf("timeout while waiting for the worlds to be saved")
+	}
+}
+
+// playerUUID returns the uuid of the player by uuid or name
+func (w *Wrapper) playerUUID(player string) (string, error) {
+	if strings.Contains(player, "-") {
+		return strings.ToLower(player), nil
+	}
+
+	p, err := w.ResolvePlayer(player)
+	if err != nil {
+		return "", err
+	}
+	return p.UUID, nil
+}
+
+// readPlayer saves the worlds and reads a file of the player by uuid or name
+func (w *Wrapper) readPlayer(player string, read func(wd *world.World, uuid string) error) error {
+	uuid, err := w.playerUUID(player)
+	if err != nil {
+		return err
+	}
+	if err := w.saveWorlds(); err != nil {
+		return err
+	}
+	return read(w.world(), uuid)
+}
+
+// PlayerData returns the inventory, position, experience and health of the player by uuid or name
+func (w *Wrapper) PlayerData(player string) (p *world.Player, err error) {
+	err = w.readPlayer(player, func(wd *world.World, uuid string) (err error) {
+		p, err = wd.Player(uuid)
+		return
+	})
+	return
+}
+
+// PlayerStatistics returns the statistics of the player by uuid or name
+func (w *Wrapper) PlayerStatistics(player string) (s world.Stats, err error) {
+	err = w.readPlayer(player, func(wd *world.World, uuid string) (err error) {
+		s, err = wd.Stats(uuid)
+		return
+	})
+	return
+}
+
+// PlayerAdvancements returns the advancements of the player by uuid or name
+func (w *Wrapper) PlayerAdvancements(player string) (a []world.Advancement, err error) {
+	err = w.readPlayer(player, func(wd *world.World, uuid string) (err error) {
+		a, err = wd.Advancements(uuid)
+		return
+	})
+	return
+}
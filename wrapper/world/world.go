@@ -0,0 +1,270 @@
+// Package world reads the player files of a world of the Minecraft Server
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/momper14/msw/wrapper/nbt"
+)
+
+// uuidRegexp uuids of players, the files are named by them
+var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// ErrInvalidUUID is returned for an invalid uuid
+var ErrInvalidUUID = errors.New("invalid uuid")
+
+// dimensions of old versions storing the dimension as number
+var dimensions = map[int64]string{
+	-1: "minecraft:the_nether",
+	0:  "minecraft:overworld",
+	1:  "minecraft:the_end",
+}
+
+// World a world directory
+type World struct {
+	dir string
+}
+
+// New returns the World in the directory
+func New(dir string) *World {
+	return &World{dir: dir}
+}
+
+// path returns the path of the file of the player in the sub directory
+func (w *World) path(sub, uuid, ext string) (string, error) {
+	if !uuidRegexp.MatchString(uuid) {
+		return "", fmt.Errorf("%w %s", ErrInvalidUUID, uuid)
+	}
+	return filepath.Join(w.dir, sub, uuid+ext), nil
+}
+
+// Stats the statistics of a player by category and key
+// minecraft:mined -> minecraft:stone -> 42
+type Stats map[string]map[string]int64
+
+// Stats reads the statistics of the player
+// files of versions before 1.13 have flat keys like stat.mineBlock.minecraft.stone
+func (w *World) Stats(uuid string) (Stats, error) {
+	path, err := w.path("stats", uuid, ".json")
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Stats Stats `json:"stats"`
+	}
+	if err := json.Unmarshal(content, &file); err == nil && file.Stats != nil {
+		return file.Stats, nil
+	}
+
+	var flat map[string]json.RawMessage
+	if err := json.Unmarshal(content, &flat); err != nil {
+		return nil, err
+	}
+	stats := make(Stats)
+	for key, raw := range flat {
+		var value int64
+		if err := json.Unmarshal(raw, &value); err != nil {
+			continue
+		}
+
+		category, name := "stat", key
+		if parts := strings.SplitN(key, ".", 3); len(parts) == 3 {
+			category, name = parts[0]+"."+parts[1], parts[2]
+		}
+		if stats[category] == nil {
+			stats[category] = make(map[string]int64)
+		}
+		stats[category][name] = value
+	}
+	return stats, nil
+}
+
+// Advancement an advancement of a player with the times its criteria were met
+type Advancement struct {
+	ID       string               `json:"id"`
+	Done     bool                 `json:"done"`
+	Criteria map[string]time.Time `json:"criteria"`
+}
+
+// advancementTimeFormat format of the times of the criteria
+const advancementTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// Advancements reads the advancements of the player, sorted by id
+// recipes are skipped, they are unlocked as advancements too
+func (w *World) Advancements(uuid string) ([]Advancement, error) {
+	path, err := w.path("advancements", uuid, ".json")
+	if err != nil {
+		return nil, err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file map[string]json.RawMessage
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+
+	advancements := make([]Advancement, 0)
+	for id, raw := range file {
+		if id == "DataVersion" || strings.Contains(id, ":recipes/") {
+			continue
+		}
+
+		var entry struct {
+			Criteria map[string]string `json:"criteria"`
+			Done     bool              `json:"done"`
+		}
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("advancement %s: %v", id, err)
+		}
+
+		a := Advancement{ID: id, Done: entry.Done, Criteria: make(map[string]time.Time)}
+		for criterion, value := range entry.Criteria {
+			t, _ := time.Parse(advancementTimeFormat, value)
+			a.Criteria[criterion] = t
+		}
+		advancements = append(advancements, a)
+	}
+
+	sort.Slice(advancements, func(i, j int) bool { return advancements[i].ID < advancements[j].ID })
+	return advancements, nil
+}
+
+// Item an item stack in a slot of an inventory
+type Item struct {
+	Slot  int    `json:"slot"`
+	ID    string `json:"id"`
+	Count int    `json:"count"`
+	// Tag the nbt of the item before 1.20.5, the components since
+	Tag nbt.Compound `json:"tag,omitempty"`
+}
+
+// Position the position of a player in a dimension
+type Position struct {
+	Dimension string  `json:"dimension"`
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Z         float64 `json:"z"`
+	Yaw       float64 `json:"yaw"`
+	Pitch     float64 `json:"pitch"`
+}
+
+// Player the data of a player
+type Player struct {
+	Position    Position  `json:"position"`
+	Spawn       *Position `json:"spawn,omitempty"`
+	GameMode    int       `json:"gameMode"`
+	Health      float64   `json:"health"`
+	Food        int       `json:"food"`
+	XPLevel     int       `json:"xpLevel"`
+	XPTotal     int       `json:"xpTotal"`
+	XPProgress  float64   `json:"xpProgress"`
+	Selected    int       `json:"selectedSlot"`
+	Inventory   []Item    `json:"inventory"`
+	EnderChest  []Item    `json:"enderChest"`
+	DataVersion int       `json:"dataVersion"`
+}
+
+// dimension returns the dimension of the tag, a string or a number in old versions
+func dimension(c nbt.Compound, key string) string {
+	if s := c.String(key); s != "" {
+		return s
+	}
+	return dimensions[c.Int(key)]
+}
+
+// items returns the items of the inventory list
+func items(list []interface{}) []Item {
+	items := make([]Item, 0, len(list))
+	for _, v := range list {
+		c, ok := v.(nbt.Compound)
+		if !ok {
+			continue
+		}
+
+		item := Item{
+			Slot:  int(c.Int("Slot")),
+			ID:    c.String("id"),
+			Count: int(c.Int("Count")),
+			Tag:   c.Compound("tag"),
+		}
+		// since 1.20.5 the count is lower case and the tag became the components
+		if _, ok := c["count"]; ok {
+			item.Count = int(c.Int("count"))
+		}
+		if components := c.Compound("components"); components != nil {
+			item.Tag = components
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Player reads the playerdata of the player
+func (w *World) Player(uuid string) (*Player, error) {
+	path, err := w.path("playerdata", uuid, ".dat")
+	if err != nil {
+		return nil, err
+	}
+	root, err := nbt.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Player{
+		GameMode:    int(root.Int("playerGameType")),
+		Health:      root.Float("Health"),
+		Food:        int(root.Int("foodLevel")),
+		XPLevel:     int(root.Int("XpLevel")),
+		XPTotal:     int(root.Int("XpTotal")),
+		XPProgress:  root.Float("XpP"),
+		Selected:    int(root.Int("SelectedItemSlot")),
+		Inventory:   items(root.List("Inventory")),
+		EnderChest:  items(root.List("EnderItems")),
+		DataVersion: int(root.Int("DataVersion")),
+	}
+
+	p.Position.Dimension = dimension(root, "Dimension")
+	if pos := root.List("Pos"); len(pos) == 3 {
+		p.Position.X, _ = pos[0].(float64)
+		p.Position.Y, _ = pos[1].(float64)
+		p.Position.Z, _ = pos[2].(float64)
+	}
+	if rot := root.List("Rotation"); len(rot) == 2 {
+		yaw, _ := rot[0].(float32)
+		pitch, _ := rot[1].(float32)
+		p.Position.Yaw, p.Position.Pitch = float64(yaw), float64(pitch)
+	}
+
+	// the spawn point is a compound since 1.21, separate tags before
+	if spawn := root.Compound("respawn"); spawn != nil {
+		p.Spawn = &Position{Dimension: spawn.String("dimension")}
+		if pos, ok := spawn["pos"].([]int32); ok && len(pos) == 3 {
+			p.Spawn.X, p.Spawn.Y, p.Spawn.Z = float64(pos[0]), float64(pos[1]), float64(pos[2])
+		}
+	} else if _, ok := root["SpawnX"]; ok {
+		p.Spawn = &Position{
+			Dimension: dimension(root, "SpawnDimension"),
+			X:         root.Float("SpawnX"),
+			Y:         root.Float("SpawnY"),
+			Z:         root.Float("SpawnZ"),
+		}
+	}
+
+	return p, nil
+}
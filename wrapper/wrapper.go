@@ -1,56 +1,79 @@
 package wrapper
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
-	"strings"
-	"syscall"
+	"sync"
 	"time"
 
 	"github.com/looplab/fsm"
 	"github.com/momper14/msw/wrapper/model"
-	"github.com/momper14/viperfix"
 	"github.com/sirupsen/logrus"
-	"github.com/spf13/viper"
 )
 
-var config struct {
-	Heapsize   int
-	Jar        string
-	Workingdir string
-	Eula       bool
-	JvmArgs    []string
-	ServerArgs []string
-}
-
-// inits viper
-func init() {
-	viper.SetDefault("mc.heapsize", 4096)
-	viper.SetDefault("mc.jar", "server.jar")
-	viper.SetDefault("mc.workingdir", "server")
-	viper.SetDefault("mc.eula", false)
-
-	if err := viperfix.UnmarshalKey("mc", &config); err != nil {
-		logrus.Fatal(err)
-	}
-}
-
 // Wrapper for the Minecraft Server
 type Wrapper struct {
-	console  *console
-	machine  *fsm.FSM
-	commands chan *model.Command
-	subs     []chan *model.Message
+	configMu     sync.RWMutex
+	config       *instanceConfig
+	next         *instanceConfig
+	backupMu     sync.Mutex
+	savedAt      time.Time
+	listsMu      sync.Mutex
+	propertiesMu sync.Mutex
+	console      *console
+	machine      *fsm.FSM
+	commands     chan *model.Command
+	subs         []chan *model.Message
+	restarter    *restarter
+	logWatchers  *logWatchers
+	parser       *eventParser
+	roster       *roster
+	rcon         *rconTransport
+	countdown    *countdown
+	scheduler    *scheduler
+	metrics      *metrics
+	sampler      *sampler
+	backupLoop   *loop
+	ticksLoop    *loop
+	statsLoop    *loop
+	statusLoop   *loop
+	probe        *statusProbe
+	queryLoop    *loop
+	query        *queryCache
+	recorder     *sessionRecorder
+	jarStage     *jarStage
+	history      *history
 }
 
 // NewWrapper initialises a new Wrapper
-func NewWrapper() *Wrapper {
+func NewWrapper(c *instanceConfig) *Wrapper {
 	wrapper := &Wrapper{
-		console:  nil,
-		commands: make(chan *model.Command),
+		config:      c,
+		next:        c,
+		console:     nil,
+		commands:    make(chan *model.Command),
+		restarter:   &restarter{},
+		logWatchers: &logWatchers{},
+		parser:      newEventParser(),
+		roster:      newRoster(),
+		countdown:   &countdown{},
+		scheduler:   &scheduler{jobs: make(map[string]*scheduledJob)},
+		metrics:     newMetrics(c.Name),
+		sampler:     newSampler(statsSize(c.Stats)),
+		history:     &history{},
+		probe:       newStatusProbe(),
+		query:       newQueryCache(),
+		recorder:    newSessionRecorder(),
+		jarStage:    &jarStage{},
 	}
+	wrapper.rcon = &rconTransport{wrapper: wrapper}
+	wrapper.backupLoop = &loop{run: wrapper.scheduleBackups}
+	wrapper.ticksLoop = &loop{run: wrapper.pollTicks}
+	wrapper.statsLoop = &loop{run: wrapper.sampleStats}
+	wrapper.statusLoop = &loop{run: wrapper.probeStatus}
+	wrapper.queryLoop = &loop{run: wrapper.pollQuery}
 	wrapper.machine = fsm.NewFSM(
 		ServerOffline.String(),
 		fsm.Events{
@@ -83,8 +106,36 @@ func NewWrapper() *Wrapper {
 	return wrapper
 }
 
+// Name returns the name of the instance
+func (w *Wrapper) Name() string {
+	return w.conf().Name
+}
+
+// Workingdir returns the working directory of the Minecraft Server
+func (w *Wrapper) Workingdir() string {
+	return w.conf().Workingdir
+}
+
 // enterState callpack for state change of the state machine
 func (w *Wrapper) enterState(e *fsm.Event) {
+	w.metrics.transitions.WithLabelValues(e.Src, e.Dst).Inc()
+
+	switch ServerStateFor(e.Dst) {
+	case ServerStopping:
+		w.restarter.stopped()
+	case ServerOnline:
+		w.restarter.online()
+		w.metrics.online()
+	case ServerOffline:
+		w.abortCountdown()
+		w.rcon.close()
+		w.metrics.offline()
+		w.closeSessions()
+		if w.roster.clear() {
+			defer w.publishPlayers()
+		}
+	}
+
 	w.publish(&model.Message{
 		Type:    model.TypeState,
 		Payload: e.Dst,
@@ -93,6 +144,7 @@ func (w *Wrapper) enterState(e *fsm.Event) {
 
 // publish publishes messages to all subscribers
 func (w *Wrapper) publish(msg *model.Message) {
+	w.metrics.published.WithLabelValues(msg.Type.String()).Inc()
 	for _, s := range w.subs {
 		s <- msg
 	}
@@ -110,10 +162,6 @@ func (w *Wrapper) processLogEvents() {
 	for {
 		line, err := w.console.ReadLine()
 		if err == io.EOF {
-
-			if err := w.updateState(StoppedEvent); err != nil {
-				logrus.Warn(err)
-			}
 			break
 		}
 
@@ -128,8 +176,14 @@ func (w *Wrapper) processLogEvents() {
 
 		ll, err := parseToLogLine(line)
 		if err == nil {
-			logToConsole(ll)
-			if err := w.updateState(ll.toEvent()); err != nil {
+			w.logToConsole(ll)
+			w.logWatchers.notify(ll.output)
+			w.metrics.parseTicks(ll.output)
+			if msg := w.parser.parse(ll); msg != nil {
+				w.publish(msg)
+				w.updatePlayers(msg)
+			}
+			if err := w.updateLogState(ll.toEvent()); err != nil {
 				logrus.Error(err)
 			}
 		} else {
@@ -138,6 +192,20 @@ func (w *Wrapper) processLogEvents() {
 	}
 }
 
+// waitForExit waits until the Minecraft Server exited
+// and applies the restart policy
+func (w *Wrapper) waitForExit(c *console, readers *sync.WaitGroup) {
+	readers.Wait()
+	exitErr := c.Wait()
+	stopping, requested := w.restarter.run()
+
+	if err := w.updateState(StoppedEvent); err != nil {
+		logrus.Warn(err)
+	}
+
+	w.exited(exitErr, stopping, requested)
+}
+
 // processErrEvents processes error events from the Minecraft Server
 func (w *Wrapper) processErrEvents() {
 	for {
@@ -154,18 +222,22 @@ func (w *Wrapper) processErrEvents() {
 }
 
 // logToConsole logs a log from the Minecraft Server to the console
-func logToConsole(ll *logLine) {
-	var fn func(...interface{})
+// tagged with the instance
+func (w *Wrapper) logToConsole(ll *logLine) {
+	var (
+		fn  func(...interface{})
+		log = logrus.WithField("instance", w.Name())
+	)
 
 	switch ll.level {
 	case "INFO":
-		fn = logrus.Info
+		fn = log.Info
 	case "WARN":
-		fn = logrus.Warn
+		fn = log.Warn
 	case "ERROR":
-		fn = logrus.Error
+		fn = log.Error
 	default:
-		fn = logrus.Print
+		fn = log.Print
 	}
 
 	fn(ll.output)
@@ -197,6 +269,22 @@ func (w *Wrapper) updateState(ev Event) error {
 	return w.machine.Event(ev.String())
 }
 
+// updateLogState updates the state by an event of the log
+// start already went Starting before launching the process
+// and the status probe may have seen the server Online before the log
+func (w *Wrapper) updateLogState(ev Event) error {
+	if ev == StartEvent {
+		return nil
+	}
+
+	err := w.updateState(ev)
+	var invalid fsm.InvalidEventError
+	if ev == StartedEvent && errors.As(err, &invalid) && invalid.State == ServerOnline.String() {
+		return nil
+	}
+	return err
+}
+
 // CurrentState returns the current state of the Minecraft server
 func (w *Wrapper) CurrentState() ServerState {
 	return ServerStateFor(w.machine.Current())
@@ -238,57 +326,10 @@ func (w *Wrapper) WaitUntilOffline(timeout time.Duration) error {
 	return fmt.Errorf("timeout")
 }
 
-// processCommands processes commands from the commands channel
-func (w *Wrapper) processCommands() {
-	for command := range w.commands {
-		var err error
-		target := command.Target
-		payload := command.Payload
-
-		if !target.Validate() {
-			logrus.Warnf("invalid target %s", target)
-		}
-
-		logrus.Infof("recieved command \"%s\"\n", payload)
-		w.publishLog(payload)
-
-		switch target {
-		case model.TargetServer:
-			err = w.console.WriteCmd(payload)
-
-		case model.TargetWrapper:
-			switch payload {
-			case "start":
-				if w.CurrentState() != ServerOnline {
-					err = w.Start()
-				} else {
-					w.publishLog("server already running!")
-				}
-			case "restart":
-				err = w.Restart()
-			case "stop":
-				cs := w.CurrentState()
-				if cs == ServerStarting || cs == ServerOnline {
-					err = w.Stop()
-				} else {
-					w.publishLog("server not running!")
-				}
-			default:
-				logrus.Warnf("unknown wrapper command: %s", payload)
-			}
-		}
-
-		if err != nil {
-			logrus.Error(err)
-			w.publishLog(err.Error())
-		}
-	}
-}
-
 // eula sets the eula if mc.eula=true
-func eula() {
-	if config.Eula {
-		f, err := os.OpenFile(config.Workingdir+"/eula.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+func (w *Wrapper) eula() {
+	if w.conf().Eula {
+		f, err := os.OpenFile(w.conf().Workingdir+"/eula.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
 		if err != nil {
 			logrus.Error(err)
 		}
@@ -300,56 +341,88 @@ func eula() {
 	}
 }
 
-// calculateArgs calculates the right memory values based on the heap size
-func calculateArgs() string {
-	heapSize := config.Heapsize
-	jar := config.Jar
-	nurseryMinimum := heapSize / 2
-	nurseryMaximum := heapSize * 4 / 5
-	args := fmt.Sprintf("-Xms%[1]dM -Xmx%[1]dM -Xmns%[2]dM -Xmnx%[3]dM -Xgc:concurrentScavenge -Xgc:dnssExpectedTimeRatioMaximum=3 -Xgc:scvNoAdaptiveTenure -Xdisableexplicitgc -Xtune:virtualized %[5]s -jar %[4]s nogui %[6]s",
-		heapSize, nurseryMinimum, nurseryMaximum, jar, strings.Join(config.JvmArgs, " "), strings.Join(config.ServerArgs, " "))
-	args = strings.Trim(args, " ")
-	args = strings.ReplaceAll(args, "  ", " ")
-	return args
-}
-
-// javaExecCmd setup the java exec
-func javaExecCmd() *exec.Cmd {
-	cmd := exec.Command("java", strings.Split(calculateArgs(), " ")...)
-	cmd.Dir = config.Workingdir
-	logrus.Infof("Executing %s in %s", cmd.Args, cmd.Dir)
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Pdeathsig: syscall.SIGTERM,
-		Setpgid:   true,
-	}
-
-	return cmd
-}
-
 // Run starts the Minecraft Server Wrapper
 func (w *Wrapper) Run() error {
 	go w.processCommands()
+	w.backupLoop.restart()
+	w.ticksLoop.restart()
+	w.statsLoop.restart()
+	w.statusLoop.restart()
+	w.queryLoop.restart()
+	w.runScheduler()
 	return w.Start()
 }
 
 // Start starts the Minecraft Server and the event processing
 func (w *Wrapper) Start() error {
-	w.console = newConsole(javaExecCmd())
+	w.restarter.cancel()
+	w.restarter.clear()
+	return w.start()
+}
 
-	eula()
+// start starts the Minecraft Server without touching the restart policy
+// it is refused unless the server is Offline
+func (w *Wrapper) start() error {
+	// going Starting before launching claims the start, a concurrent start fails here
+	// and the staged jars can't change while they are applied
+	if err := w.updateState(StartEvent); err != nil {
+		return fmt.Errorf("%w: server is %s", ErrServerBusy, w.CurrentState())
+	}
 
-	go w.processLogEvents()
-	go w.processErrEvents()
-	return w.console.Start()
+	if err := w.launch(); err != nil {
+		if err := w.updateState(StoppedEvent); err != nil {
+			logrus.Warn(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// launch applies the pending changes and launches the process of the Minecraft Server
+func (w *Wrapper) launch() error {
+	w.restarter.reset()
+	w.applyPending()
+	w.applyStagedJars()
+	cmd, err := w.launchCmd()
+	if err != nil {
+		return err
+	}
+	w.console = newConsole(cmd)
+
+	w.eula()
+
+	if err := w.console.Start(); err != nil {
+		return err
+	}
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		w.processLogEvents()
+	}()
+	go func() {
+		defer readers.Done()
+		w.processErrEvents()
+	}()
+	go w.waitForExit(w.console, &readers)
+
+	return nil
 }
 
 // Stop stops the Minecraft Server
 func (w *Wrapper) Stop() error {
+	if w.console == nil {
+		return fmt.Errorf("%w: server is %s", ErrServerBusy, w.CurrentState())
+	}
+	w.restarter.request()
 	return w.console.WriteCmd("stop")
 }
 
 // Restart restarts the Minecraft Server
 func (w *Wrapper) Restart() error {
+	w.metrics.restarts.WithLabelValues("requested").Inc()
+
 	if w.CurrentState() == ServerOnline {
 		if err := w.Stop(); err != nil {
 			return err